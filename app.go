@@ -2,6 +2,7 @@ package main
 
 import (
 	"archive/zip"
+	"bytes"
 	"context"
 	"database/sql"
 	"encoding/base64"
@@ -10,8 +11,10 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"runtime"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	_ "github.com/mattn/go-sqlite3"
@@ -21,11 +24,25 @@ import (
 type App struct {
 	ctx context.Context
 	db  *sql.DB
+
+	parserMu       sync.Mutex
+	fileNameParser *fileNameParser
+	ignoreMu       sync.Mutex
+	ignoreRules    *IgnoreRulesConfig
+
+	imagePool      *imageWorkerPool
+	pageCache      *pageCache
+	archiveCache   *pageCache
+	thumbnailCache *pageCache
+
+	jobs jobManager
 }
 
 // NewApp creates a new App application struct
 func NewApp() *App {
-	return &App{}
+	return &App{
+		imagePool: newImageWorkerPool(runtime.NumCPU()),
+	}
 }
 
 // startup is called when the app starts. The context is saved
@@ -33,6 +50,11 @@ func NewApp() *App {
 func (a *App) startup(ctx context.Context) {
 	a.ctx = ctx
 
+	// 初始化图片磁盘缓存
+	a.pageCache = newPageCache(defaultPageCacheBytes)
+	a.archiveCache = newDiskCache("archives", defaultArchiveCacheBytes)
+	a.thumbnailCache = newDiskCache("thumbnails", defaultThumbnailCacheBytes)
+
 	// 初始化数据库
 	err := a.initDatabase()
 	if err != nil {
@@ -77,8 +99,8 @@ func (a *App) HandleFileDrop(files []string) {
 			}
 			fileType = "folder"
 			fmt.Printf("从文件夹 %s 中读取到第一张图片: %s\n", file, firstImage)
-		} else if strings.HasSuffix(strings.ToLower(file), ".zip") {
-			// 处理zip文件
+		} else if archiveExtensions[strings.ToLower(filepath.Ext(file))] {
+			// 处理zip/cbz文件
 			firstImage, err = a.getFirstImageFromZip(file)
 			if err != nil {
 				fmt.Printf("读取zip文件 %s 失败: %v\n", file, err)
@@ -94,8 +116,14 @@ func (a *App) HandleFileDrop(files []string) {
 		err = a.saveComicToDatabase(file, fileType, firstImage, fileInfo.Size())
 		if err != nil {
 			fmt.Printf("保存到数据库失败 %s: %v\n", file, err)
-		} else {
-			fmt.Printf("成功保存到数据库: %s\n", file)
+			continue
+		}
+		fmt.Printf("成功保存到数据库: %s\n", file)
+
+		// 解析文件名并归入系列
+		err = a.indexComicByPath(file)
+		if err != nil {
+			fmt.Printf("解析漫画信息失败 %s: %v\n", file, err)
 		}
 	}
 }
@@ -110,7 +138,7 @@ func (a *App) getFirstImageFromZip(zipPath string) (string, error) {
 	defer reader.Close()
 
 	// 使用广度优先搜索获取所有图片文件
-	imageFiles := a.bfsSearchImages(reader.File)
+	imageFiles := a.bfsSearchImages(reader.File, a.ignoreMatcherFor(zipPath))
 
 	fmt.Printf("找到 %d 个图片文件\n", len(imageFiles))
 	if len(imageFiles) > 0 {
@@ -118,6 +146,10 @@ func (a *App) getFirstImageFromZip(zipPath string) (string, error) {
 	}
 
 	if len(imageFiles) == 0 {
+		// 卷压缩包中只有各话的压缩包时，取内层压缩包中的第一页
+		if pages, err := a.listZipPages(zipPath); err == nil && len(pages) > 0 {
+			return pages[0].Path, nil
+		}
 		return "", fmt.Errorf("zip文件中没有找到图片文件")
 	}
 
@@ -190,7 +222,7 @@ func (a *App) getFirstImageFromZip(zipPath string) (string, error) {
 // getFirstImageFromFolder 从普通文件夹中读取第一个图片（广度优先搜索子目录）
 func (a *App) getFirstImageFromFolder(folderPath string) (string, error) {
 	// 使用广度优先搜索获取所有图片文件
-	imageFiles, err := a.bfsSearchImagesFromFolder(folderPath)
+	imageFiles, err := a.bfsSearchImagesFromFolder(folderPath, a.ignoreMatcherFor(folderPath))
 	if err != nil {
 		return "", fmt.Errorf("搜索文件夹失败: %v", err)
 	}
@@ -231,7 +263,7 @@ func (a *App) initDatabase() error {
 	dbPath := "comic.db"
 
 	// 打开数据库连接
-	db, err := sql.Open("sqlite3", dbPath)
+	db, err := sql.Open(naturalSQLiteDriver, dbPath)
 	if err != nil {
 		return fmt.Errorf("打开数据库失败: %v", err)
 	}
@@ -249,6 +281,8 @@ func (a *App) initDatabase() error {
 		return fmt.Errorf("创建表失败: %v", err)
 	}
 
+	a.loadSortLocale()
+
 	fmt.Printf("数据库初始化成功: %s\n", dbPath)
 	return nil
 }
@@ -293,18 +327,138 @@ func (a *App) createTables() error {
 		return fmt.Errorf("创建images表失败: %v", err)
 	}
 
+	// 阅读进度字段
+	progressColumns := [][2]string{
+		{"last_page", "INTEGER DEFAULT 0"},
+		{"page_count", "INTEGER DEFAULT 0"},
+		{"is_read", "INTEGER DEFAULT 0"},
+		{"last_read_at", "DATETIME"},
+	}
+	for _, column := range progressColumns {
+		if err := a.ensureColumn("comics", column[0], column[1]); err != nil {
+			return err
+		}
+	}
+
+	// 文件名解析字段
+	fileNameColumns := [][2]string{
+		{"release_year", "INTEGER"},
+		{"scan_group", "TEXT"},
+		{"edition_tags", "TEXT"},
+	}
+	for _, column := range fileNameColumns {
+		if err := a.ensureColumn("comics", column[0], column[1]); err != nil {
+			return err
+		}
+	}
+
+	err = a.createUserDataColumns()
+	if err != nil {
+		return err
+	}
+
+	err = a.createSettingsTables()
+	if err != nil {
+		return err
+	}
+
+	err = a.createSeriesTables()
+	if err != nil {
+		return err
+	}
+
+	err = a.createReadingModeColumns()
+	if err != nil {
+		return err
+	}
+
+	err = a.createPageIndexColumns()
+	if err != nil {
+		return err
+	}
+
+	err = a.createCropColumns()
+	if err != nil {
+		return err
+	}
+
+	err = a.createBookmarkTables()
+	if err != nil {
+		return err
+	}
+
+	err = a.createReadingSessionTables()
+	if err != nil {
+		return err
+	}
+
+	err = a.createTagTables()
+	if err != nil {
+		return err
+	}
+
+	err = a.createCollectionTables()
+	if err != nil {
+		return err
+	}
+
+	err = a.createCoverColumns()
+	if err != nil {
+		return err
+	}
+
+	err = a.createDuplicateColumns()
+	if err != nil {
+		return err
+	}
+
+	err = a.createIntegrityTables()
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
-// bfsSearchImages 使用广度优先搜索算法搜索图片文件
-func (a *App) bfsSearchImages(files []*zip.File) []string {
-	var imageFiles []string
-	imageExtensions := map[string]bool{
-		".jpg": true, ".jpeg": true, ".png": true, ".gif": true,
-		".bmp": true, ".webp": true, ".tiff": true, ".tif": true,
+// ensureColumn 为已存在的表补充缺失的字段，用于旧数据库升级
+func (a *App) ensureColumn(table, column, definition string) error {
+	rows, err := a.db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return fmt.Errorf("读取%s表结构失败: %v", table, err)
+	}
+
+	exists := false
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, colType string
+		var defaultValue sql.NullString
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &defaultValue, &pk); err != nil {
+			rows.Close()
+			return fmt.Errorf("读取%s表结构失败: %v", table, err)
+		}
+		if name == column {
+			exists = true
+		}
 	}
+	rows.Close()
+
+	if exists {
+		return nil
+	}
+
+	_, err = a.db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition))
+	if err != nil {
+		return fmt.Errorf("为%s表添加字段%s失败: %v", table, column, err)
+	}
+
+	return nil
+}
+
+// bfsSearchImages 使用广度优先搜索算法搜索图片文件，跳过matcher排除的条目
+func (a *App) bfsSearchImages(files []*zip.File, matcher *ignoreMatcher) []string {
+	var imageFiles []string
 
-	// 构建目录结构
+	// 构建目录结构，只保留图片条目
 	dirs := make(map[string][]string)
 	var rootFiles []string
 
@@ -313,6 +467,9 @@ func (a *App) bfsSearchImages(files []*zip.File) []string {
 		if strings.HasSuffix(file.Name, "/") {
 			continue
 		}
+		if matcher.skipEntry(file.Name) || !isImageEntry(file.Name, file.Open) {
+			continue
+		}
 
 		// 获取文件所在目录
 		dir := filepath.Dir(file.Name)
@@ -327,27 +484,14 @@ func (a *App) bfsSearchImages(files []*zip.File) []string {
 
 	// 广度优先搜索：按层级搜索
 	// 1. 先检查根目录的图片文件
-	for _, fileName := range rootFiles {
-		ext := strings.ToLower(filepath.Ext(fileName))
-		if imageExtensions[ext] {
-			imageFiles = append(imageFiles, fileName)
-		}
-	}
+	imageFiles = append(imageFiles, rootFiles...)
 
-	// 2. 如果根目录没有图片，按目录层级广度优先搜索子目录
-	if len(imageFiles) == 0 {
-		// 按目录层级排序（广度优先）
-		sortedDirs := a.sortDirectoriesByLevel(dirs)
-
-		// 遍历每个目录层级
-		for _, dir := range sortedDirs {
-			for _, fileName := range dirs[dir] {
-				ext := strings.ToLower(filepath.Ext(fileName))
-				if imageExtensions[ext] {
-					imageFiles = append(imageFiles, fileName)
-				}
-			}
-		}
+	// 2. 再按目录层级广度优先搜索子目录，根目录有封面时子目录中的章节也要保留
+	sortedDirs := a.sortDirectoriesByLevel(dirs)
+
+	// 遍历每个目录层级
+	for _, dir := range sortedDirs {
+		imageFiles = append(imageFiles, dirs[dir]...)
 	}
 
 	return imageFiles
@@ -367,26 +511,27 @@ func (a *App) sortDirectoriesByLevel(dirs map[string][]string) []string {
 		levelGroups[level] = append(levelGroups[level], dir)
 	}
 
-	// 按层级顺序构建结果
+	// 按层级顺序构建结果，中间层级没有图片时不能按数量遍历，否则会漏掉更深的目录
+	levels := make([]int, 0, len(levelGroups))
+	for level := range levelGroups {
+		levels = append(levels, level)
+	}
+	sort.Ints(levels)
+
 	var result []string
-	for level := 1; level <= len(levelGroups); level++ {
-		if dirs, exists := levelGroups[level]; exists {
-			// 同一层级内按目录名排序
-			sort.Strings(dirs)
-			result = append(result, dirs...)
-		}
+	for _, level := range levels {
+		dirs := levelGroups[level]
+		// 同一层级内按目录名排序
+		sort.Slice(dirs, func(i, j int) bool { return comparePagePaths(dirs[i]+"/", dirs[j]+"/") < 0 })
+		result = append(result, dirs...)
 	}
 
 	return result
 }
 
-// bfsSearchImagesFromFolder 使用广度优先搜索算法从文件夹中搜索图片文件
-func (a *App) bfsSearchImagesFromFolder(folderPath string) ([]string, error) {
+// bfsSearchImagesFromFolder 使用广度优先搜索算法从文件夹中搜索图片文件，跳过matcher排除的文件
+func (a *App) bfsSearchImagesFromFolder(folderPath string, matcher *ignoreMatcher) ([]string, error) {
 	var imageFiles []string
-	imageExtensions := map[string]bool{
-		".jpg": true, ".jpeg": true, ".png": true, ".gif": true,
-		".bmp": true, ".webp": true, ".tiff": true, ".tif": true,
-	}
 
 	fmt.Printf("开始搜索文件夹: %s\n", folderPath)
 
@@ -413,8 +558,8 @@ func (a *App) bfsSearchImagesFromFolder(folderPath string) ([]string, error) {
 		for _, entry := range entries {
 			if !entry.IsDir() {
 				filePath := filepath.Join(currentPath, entry.Name())
-				ext := strings.ToLower(filepath.Ext(entry.Name()))
-				if imageExtensions[ext] {
+				open := func() (io.ReadCloser, error) { return os.Open(filePath) }
+				if !matcher.skipEntry(relativeEntryPath(folderPath, filePath)) && isImageEntry(entry.Name(), open) {
 					imageFiles = append(imageFiles, filePath)
 				}
 			}
@@ -422,7 +567,7 @@ func (a *App) bfsSearchImagesFromFolder(folderPath string) ([]string, error) {
 
 		// 再添加子目录到队列（广度优先：同层级处理完文件后再处理子目录）
 		for _, entry := range entries {
-			if entry.IsDir() {
+			if entry.IsDir() && !matcher.ignored(relativeEntryPath(folderPath, filepath.Join(currentPath, entry.Name()))) {
 				subDirPath := filepath.Join(currentPath, entry.Name())
 				queue = append(queue, subDirPath)
 			}
@@ -441,98 +586,14 @@ func (a *App) getDirectoryLevel(dir string) int {
 	return strings.Count(dir, "/") + 1
 }
 
-// naturalSort 实现自然排序，支持混合字符串的数字排序
+// naturalSort 实现自然排序，按目录逐级比较，多章节的压缩包不会交错排列
 func (a *App) naturalSort(aStr, bStr string) bool {
-	// 提取文件名（不含扩展名）
-	aName := strings.TrimSuffix(filepath.Base(aStr), filepath.Ext(aStr))
-	bName := strings.TrimSuffix(filepath.Base(bStr), filepath.Ext(bStr))
-
-	// 使用自然排序比较
-	return a.compareNatural(aName, bName)
+	return comparePagePaths(aStr, bStr) < 0
 }
 
-// compareNatural 实现自然排序比较，支持字母数字混合
+// compareNatural 实现自然排序比较，规则见 naturalCompare
 func (a *App) compareNatural(strA, strB string) bool {
-	// 将字符串分割为数字和非数字部分
-	aParts := a.splitStringAndNumber(strA)
-	bParts := a.splitStringAndNumber(strB)
-
-	// 比较每个部分
-	minLen := len(aParts)
-	if len(bParts) < minLen {
-		minLen = len(bParts)
-	}
-
-	for i := 0; i < minLen; i++ {
-		aPart := aParts[i]
-		bPart := bParts[i]
-
-		// 如果两个部分都是数字，按数字大小比较
-		if a.isNumeric(aPart) && a.isNumeric(bPart) {
-			aNum := a.parseNumber(aPart)
-			bNum := a.parseNumber(bPart)
-			if aNum != bNum {
-				return aNum < bNum
-			}
-		} else {
-			// 否则按字符串比较
-			if aPart != bPart {
-				return aPart < bPart
-			}
-		}
-	}
-
-	// 如果前面部分都相同，长度短的排在前面
-	return len(aParts) < len(bParts)
-}
-
-// splitStringAndNumber 将字符串分割为数字和非数字部分
-func (a *App) splitStringAndNumber(s string) []string {
-	var parts []string
-	var current string
-	var isDigit bool
-
-	for _, char := range s {
-		charIsDigit := char >= '0' && char <= '9'
-
-		if len(current) == 0 {
-			// 第一个字符
-			current = string(char)
-			isDigit = charIsDigit
-		} else if charIsDigit == isDigit {
-			// 相同类型，继续累积
-			current += string(char)
-		} else {
-			// 类型改变，保存当前部分并开始新部分
-			parts = append(parts, current)
-			current = string(char)
-			isDigit = charIsDigit
-		}
-	}
-
-	// 添加最后一部分
-	if len(current) > 0 {
-		parts = append(parts, current)
-	}
-
-	return parts
-}
-
-// isNumeric 检查字符串是否为纯数字
-func (a *App) isNumeric(s string) bool {
-	for _, char := range s {
-		if char < '0' || char > '9' {
-			return false
-		}
-	}
-	return len(s) > 0
-}
-
-// parseNumber 解析数字字符串
-func (a *App) parseNumber(s string) int {
-	var num int
-	fmt.Sscanf(s, "%d", &num)
-	return num
+	return naturalCompare(strA, strB) < 0
 }
 
 // saveComicToDatabase 保存漫画信息到数据库
@@ -544,10 +605,16 @@ func (a *App) saveComicToDatabase(filePath, fileType, firstImage string, fileSiz
 	// 获取文件名作为标题
 	title := filepath.Base(filePath)
 
-	// 插入或更新漫画信息
+	// 插入或更新漫画信息，已存在时保留原有ID，避免标签、合集等关联数据失效
 	query := `
-	INSERT OR REPLACE INTO comics (title, file_path, file_type, first_image, file_size, updated_at)
-	VALUES (?, ?, ?, ?, ?, ?)`
+	INSERT INTO comics (title, file_path, file_type, first_image, file_size, updated_at)
+	VALUES (?, ?, ?, ?, ?, ?)
+	ON CONFLICT(file_path) DO UPDATE SET
+		title = excluded.title,
+		file_type = excluded.file_type,
+		first_image = CASE WHEN COALESCE(comics.cover_source, 'auto') = 'auto' THEN excluded.first_image ELSE comics.first_image END,
+		file_size = excluded.file_size,
+		updated_at = excluded.updated_at`
 
 	_, err := a.db.Exec(query, title, filePath, fileType, firstImage, fileSize, time.Now())
 	if err != nil {
@@ -559,43 +626,7 @@ func (a *App) saveComicToDatabase(filePath, fileType, firstImage string, fileSiz
 
 // GetComicsFromDatabase 从数据库获取所有漫画信息
 func (a *App) GetComicsFromDatabase() ([]map[string]interface{}, error) {
-	if a.db == nil {
-		return nil, fmt.Errorf("数据库未初始化")
-	}
-
-	query := `SELECT id, title, file_path, file_type, first_image, file_size, created_at, updated_at FROM comics ORDER BY updated_at DESC`
-
-	rows, err := a.db.Query(query)
-	if err != nil {
-		return nil, fmt.Errorf("查询漫画信息失败: %v", err)
-	}
-	defer rows.Close()
-
-	var comics []map[string]interface{}
-	for rows.Next() {
-		var id int64
-		var title, filePath, fileType, firstImage, createdAt, updatedAt string
-		var fileSize int64
-
-		err := rows.Scan(&id, &title, &filePath, &fileType, &firstImage, &fileSize, &createdAt, &updatedAt)
-		if err != nil {
-			continue
-		}
-
-		comic := map[string]interface{}{
-			"id":         id,
-			"title":      title,
-			"filePath":   filePath,
-			"fileType":   fileType,
-			"firstImage": firstImage,
-			"fileSize":   fileSize,
-			"createdAt":  createdAt,
-			"updatedAt":  updatedAt,
-		}
-		comics = append(comics, comic)
-	}
-
-	return comics, nil
+	return a.QueryComics(LibraryQuery{})
 }
 
 // DeleteComicFromDatabase 从数据库删除漫画信息
@@ -604,58 +635,27 @@ func (a *App) DeleteComicFromDatabase(comicID int64) error {
 		return fmt.Errorf("数据库未初始化")
 	}
 
+	// 先删除关联数据
+	relatedTables := []string{"images", "bookmarks", "reading_sessions", "comic_tags", "collection_comics", "integrity_problems"}
+	for _, table := range relatedTables {
+		_, err := a.db.Exec(fmt.Sprintf("DELETE FROM %s WHERE comic_id = ?", table), comicID)
+		if err != nil {
+			return fmt.Errorf("删除%s关联数据失败: %v", table, err)
+		}
+	}
+
 	query := `DELETE FROM comics WHERE id = ?`
 	_, err := a.db.Exec(query, comicID)
 	if err != nil {
 		return fmt.Errorf("删除漫画信息失败: %v", err)
 	}
 
-	return nil
+	return a.removeEmptySeries()
 }
 
 // SearchComicsInDatabase 在数据库中搜索漫画
 func (a *App) SearchComicsInDatabase(keyword string) ([]map[string]interface{}, error) {
-	if a.db == nil {
-		return nil, fmt.Errorf("数据库未初始化")
-	}
-
-	query := `SELECT id, title, file_path, file_type, first_image, file_size, created_at, updated_at 
-			  FROM comics 
-			  WHERE title LIKE ? OR file_path LIKE ? 
-			  ORDER BY updated_at DESC`
-
-	searchPattern := "%" + keyword + "%"
-	rows, err := a.db.Query(query, searchPattern, searchPattern)
-	if err != nil {
-		return nil, fmt.Errorf("搜索漫画信息失败: %v", err)
-	}
-	defer rows.Close()
-
-	var comics []map[string]interface{}
-	for rows.Next() {
-		var id int64
-		var title, filePath, fileType, firstImage, createdAt, updatedAt string
-		var fileSize int64
-
-		err := rows.Scan(&id, &title, &filePath, &fileType, &firstImage, &fileSize, &createdAt, &updatedAt)
-		if err != nil {
-			continue
-		}
-
-		comic := map[string]interface{}{
-			"id":         id,
-			"title":      title,
-			"filePath":   filePath,
-			"fileType":   fileType,
-			"firstImage": firstImage,
-			"fileSize":   fileSize,
-			"createdAt":  createdAt,
-			"updatedAt":  updatedAt,
-		}
-		comics = append(comics, comic)
-	}
-
-	return comics, nil
+	return a.QueryComics(LibraryQuery{Keyword: keyword})
 }
 
 // GetImageData 获取图片数据，支持comic://协议
@@ -686,26 +686,18 @@ func (a *App) GetImageBase64(imagePath string) (string, error) {
 		return "", err
 	}
 
-	// 获取文件扩展名来确定MIME类型
-	ext := strings.ToLower(filepath.Ext(imagePath))
-	var mimeType string
-	switch ext {
-	case ".jpg", ".jpeg":
-		mimeType = "image/jpeg"
-	case ".png":
-		mimeType = "image/png"
-	case ".gif":
-		mimeType = "image/gif"
-	case ".bmp":
-		mimeType = "image/bmp"
-	case ".webp":
-		mimeType = "image/webp"
-	case ".tiff", ".tif":
-		mimeType = "image/tiff"
-	default:
-		mimeType = "image/jpeg" // 默认
+	// webview无法显示的新格式先转码
+	if format := detectModernImageFormat(imageData); format != "" && !webviewDisplayable[format] {
+		data, contentType, err := transformImage(bytes.NewReader(imageData), imageTransform{}, nil)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("data:%s;base64,%s", contentType, base64.StdEncoding.EncodeToString(data)), nil
 	}
 
+	// 根据文件内容确定MIME类型，扩展名错误或没有扩展名时也能正确显示
+	mimeType := pageContentType(imageData, imagePath)
+
 	// 转换为Base64
 	base64Data := fmt.Sprintf("data:%s;base64,%s", mimeType, base64.StdEncoding.EncodeToString(imageData))
 	return base64Data, nil
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"image"
+	"image/color"
+)
+
+const (
+	// cropTolerance 与边框颜色的亮度差在该范围内视为同色
+	cropTolerance = 24
+	// cropNoiseRatio 一行或一列中允许的杂点比例，避免扫描噪点阻止裁剪
+	cropNoiseRatio = 0.005
+	// cropMaxRatio 单方向最多裁掉的比例，超过时视为空白页不裁剪
+	cropMaxRatio = 0.4
+)
+
+// createCropColumns 为图片表添加裁剪区域缓存字段
+func (a *App) createCropColumns() error {
+	cropColumns := [][2]string{
+		{"crop_detected", "INTEGER DEFAULT 0"},
+		{"crop_x0", "INTEGER DEFAULT 0"},
+		{"crop_y0", "INTEGER DEFAULT 0"},
+		{"crop_x1", "INTEGER DEFAULT 0"},
+		{"crop_y1", "INTEGER DEFAULT 0"},
+	}
+	for _, column := range cropColumns {
+		if err := a.ensureColumn("images", column[0], column[1]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// luminance 获取像素亮度，YCbCr和灰度图直接读取避免颜色转换
+func luminance(img image.Image, x, y int) uint8 {
+	switch src := img.(type) {
+	case *image.YCbCr:
+		return src.Y[src.YOffset(x, y)]
+	case *image.Gray:
+		return src.Pix[src.PixOffset(x, y)]
+	default:
+		return color.GrayModel.Convert(img.At(x, y)).(color.Gray).Y
+	}
+}
+
+// nearColor 判断亮度是否在容差范围内
+func nearColor(value, reference uint8) bool {
+	diff := int(value) - int(reference)
+	return diff >= -cropTolerance && diff <= cropTolerance
+}
+
+// uniformLine 判断一行或一列是否为同色边框
+func uniformLine(img image.Image, reference uint8, horizontal bool, fixed, from, to int) bool {
+	allowed := int(float64(to-from) * cropNoiseRatio)
+	noise := 0
+	for i := from; i < to; i++ {
+		var value uint8
+		if horizontal {
+			value = luminance(img, i, fixed)
+		} else {
+			value = luminance(img, fixed, i)
+		}
+		if !nearColor(value, reference) {
+			noise++
+			if noise > allowed {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// detectCropBox 检测图片四周的白边或黑边，返回保留的内容区域
+func detectCropBox(img image.Image) image.Rectangle {
+	bounds := img.Bounds()
+	if bounds.Dx() < 3 || bounds.Dy() < 3 {
+		return bounds
+	}
+
+	topLeft := luminance(img, bounds.Min.X, bounds.Min.Y)
+	bottomRight := luminance(img, bounds.Max.X-1, bounds.Max.Y-1)
+
+	top := bounds.Min.Y
+	for top < bounds.Max.Y-1 && uniformLine(img, topLeft, true, top, bounds.Min.X, bounds.Max.X) {
+		top++
+	}
+	bottom := bounds.Max.Y
+	for bottom > top+1 && uniformLine(img, bottomRight, true, bottom-1, bounds.Min.X, bounds.Max.X) {
+		bottom--
+	}
+	left := bounds.Min.X
+	for left < bounds.Max.X-1 && uniformLine(img, topLeft, false, left, top, bottom) {
+		left++
+	}
+	right := bounds.Max.X
+	for right > left+1 && uniformLine(img, bottomRight, false, right-1, top, bottom) {
+		right--
+	}
+
+	box := image.Rect(left, top, right, bottom)
+	if float64(box.Dx()) < float64(bounds.Dx())*(1-2*cropMaxRatio) || float64(box.Dy()) < float64(bounds.Dy())*(1-2*cropMaxRatio) {
+		return bounds
+	}
+	if (top-bounds.Min.Y) > int(float64(bounds.Dy())*cropMaxRatio) || (bounds.Max.Y-bottom) > int(float64(bounds.Dy())*cropMaxRatio) ||
+		(left-bounds.Min.X) > int(float64(bounds.Dx())*cropMaxRatio) || (bounds.Max.X-right) > int(float64(bounds.Dx())*cropMaxRatio) {
+		return bounds
+	}
+
+	return box
+}
+
+// pageCropBox 获取页面的裁剪区域，优先使用images表中的缓存，没有时检测并保存
+func (a *App) pageCropBox(comicID int64, pageIndex int, img image.Image) (image.Rectangle, error) {
+	if a.db == nil {
+		return image.Rectangle{}, fmt.Errorf("数据库未初始化")
+	}
+
+	var detected bool
+	var x0, y0, x1, y1 int
+	err := a.db.QueryRow(`SELECT COALESCE(crop_detected, 0), COALESCE(crop_x0, 0), COALESCE(crop_y0, 0), COALESCE(crop_x1, 0), COALESCE(crop_y1, 0)
+		FROM images WHERE comic_id = ? AND page_index = ?`, comicID, pageIndex).Scan(&detected, &x0, &y0, &x1, &y1)
+	if err != nil && err != sql.ErrNoRows {
+		return image.Rectangle{}, fmt.Errorf("查询裁剪区域失败: %v", err)
+	}
+
+	if detected {
+		box := image.Rect(x0, y0, x1, y1).Intersect(img.Bounds())
+		if !box.Empty() {
+			return box, nil
+		}
+	}
+
+	box := detectCropBox(img)
+	_, err = a.db.Exec(`UPDATE images SET crop_detected = 1, crop_x0 = ?, crop_y0 = ?, crop_x1 = ?, crop_y1 = ? WHERE comic_id = ? AND page_index = ?`,
+		box.Min.X, box.Min.Y, box.Max.X, box.Max.Y, comicID, pageIndex)
+	if err != nil {
+		return box, fmt.Errorf("保存裁剪区域失败: %v", err)
+	}
+
+	return box, nil
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+// grayPage 生成背景为background、content区域为foreground的灰度图
+func grayPage(width, height int, background, foreground uint8, content image.Rectangle) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			value := background
+			if image.Pt(x, y).In(content) {
+				value = foreground
+			}
+			img.SetGray(x, y, color.Gray{Y: value})
+		}
+	}
+	return img
+}
+
+// TestDetectCropBox 裁掉四周的白边和黑边，边框过宽或没有边框时保持原样
+func TestDetectCropBox(t *testing.T) {
+	noisy := grayPage(400, 400, 255, 0, image.Rect(40, 40, 360, 360))
+	noisy.SetGray(200, 0, color.Gray{Y: 0})
+	noisy.SetGray(0, 200, color.Gray{Y: 0})
+
+	checker := image.NewGray(image.Rect(0, 0, 100, 100))
+	for y := 0; y < 100; y++ {
+		for x := 0; x < 100; x++ {
+			if (x+y)%2 == 0 {
+				checker.SetGray(x, y, color.Gray{Y: 255})
+			}
+		}
+	}
+
+	framed := grayPage(200, 200, 255, 0, image.Rect(60, 70, 140, 130))
+
+	tests := []struct {
+		name string
+		img  image.Image
+		want image.Rectangle
+	}{
+		{"white margins", grayPage(100, 100, 255, 0, image.Rect(10, 10, 90, 90)), image.Rect(10, 10, 90, 90)},
+		{"black margins", grayPage(100, 100, 0, 128, image.Rect(5, 20, 95, 80)), image.Rect(5, 20, 95, 80)},
+		{"near white margins", grayPage(100, 100, 240, 0, image.Rect(10, 15, 85, 90)), image.Rect(10, 15, 85, 90)},
+		{"wide margins within limit", grayPage(100, 100, 255, 0, image.Rect(30, 30, 70, 70)), image.Rect(30, 30, 70, 70)},
+		{"margins beyond limit", grayPage(100, 100, 255, 0, image.Rect(45, 45, 55, 55)), image.Rect(0, 0, 100, 100)},
+		{"blank page", grayPage(100, 100, 255, 0, image.Rectangle{}), image.Rect(0, 0, 100, 100)},
+		{"no margins", checker, image.Rect(0, 0, 100, 100)},
+		{"scan noise in margin", noisy, image.Rect(40, 40, 360, 360)},
+		{"too small", grayPage(2, 2, 255, 0, image.Rectangle{}), image.Rect(0, 0, 2, 2)},
+		{"sub image", framed.SubImage(image.Rect(50, 50, 150, 150)), image.Rect(60, 70, 140, 130)},
+	}
+
+	for _, tt := range tests {
+		if got := detectCropBox(tt.img); got != tt.want {
+			t.Errorf("%s: detectCropBox = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// createBookmarkTables 创建书签表，一本漫画可以有多个书签
+func (a *App) createBookmarkTables() error {
+	createBookmarkTable := `
+	CREATE TABLE IF NOT EXISTS bookmarks (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		comic_id INTEGER NOT NULL,
+		page_index INTEGER NOT NULL,
+		name TEXT NOT NULL,
+		note TEXT DEFAULT '',
+		thumbnail TEXT DEFAULT '',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (comic_id) REFERENCES comics (id)
+	);`
+
+	_, err := a.db.Exec(createBookmarkTable)
+	if err != nil {
+		return fmt.Errorf("创建bookmarks表失败: %v", err)
+	}
+
+	return nil
+}
+
+// AddBookmark 在指定页添加书签，未提供缩略图时使用该页图片
+func (a *App) AddBookmark(comicID int64, pageIndex int, name, note, thumbnail string) (int64, error) {
+	pages, err := a.loadComicPages(comicID)
+	if err != nil {
+		return 0, err
+	}
+	if pageIndex < 0 || pageIndex >= len(pages) {
+		return 0, fmt.Errorf("页码超出范围: %d，共 %d 页", pageIndex, len(pages))
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = fmt.Sprintf("第 %d 页", pageIndex+1)
+	}
+	if thumbnail == "" {
+		thumbnail = pages[pageIndex].Path
+	}
+
+	result, err := a.db.Exec(`INSERT INTO bookmarks (comic_id, page_index, name, note, thumbnail) VALUES (?, ?, ?, ?, ?)`,
+		comicID, pageIndex, name, note, thumbnail)
+	if err != nil {
+		return 0, fmt.Errorf("添加书签失败: %v", err)
+	}
+
+	return result.LastInsertId()
+}
+
+// UpdateBookmark 修改书签名称和备注
+func (a *App) UpdateBookmark(bookmarkID int64, name, note string) error {
+	if a.db == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return fmt.Errorf("书签名不能为空")
+	}
+
+	_, err := a.db.Exec(`UPDATE bookmarks SET name = ?, note = ?, updated_at = ? WHERE id = ?`, name, note, time.Now(), bookmarkID)
+	if err != nil {
+		return fmt.Errorf("更新书签失败: %v", err)
+	}
+
+	return nil
+}
+
+// DeleteBookmark 删除书签
+func (a *App) DeleteBookmark(bookmarkID int64) error {
+	if a.db == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+
+	_, err := a.db.Exec(`DELETE FROM bookmarks WHERE id = ?`, bookmarkID)
+	if err != nil {
+		return fmt.Errorf("删除书签失败: %v", err)
+	}
+
+	return nil
+}
+
+// queryBookmarks 查询书签并附带漫画标题和页面路径
+func (a *App) queryBookmarks(where, orderBy string, args ...interface{}) ([]map[string]interface{}, error) {
+	if a.db == nil {
+		return nil, fmt.Errorf("数据库未初始化")
+	}
+
+	query := `
+	SELECT b.id, b.comic_id, c.title, b.page_index, COALESCE(i.file_path, ''), b.name, COALESCE(b.note, ''), COALESCE(b.thumbnail, ''), b.created_at
+	FROM bookmarks b
+	JOIN comics c ON c.id = b.comic_id
+	LEFT JOIN images i ON i.comic_id = b.comic_id AND i.page_index = b.page_index
+	` + where + `
+	ORDER BY ` + orderBy
+
+	rows, err := a.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("查询书签失败: %v", err)
+	}
+	defer rows.Close()
+
+	var bookmarks []map[string]interface{}
+	for rows.Next() {
+		var id, comicID int64
+		var pageIndex int
+		var comicTitle, pagePath, name, note, thumbnail, createdAt string
+
+		if err := rows.Scan(&id, &comicID, &comicTitle, &pageIndex, &pagePath, &name, &note, &thumbnail, &createdAt); err != nil {
+			continue
+		}
+
+		bookmarks = append(bookmarks, map[string]interface{}{
+			"id":         id,
+			"comicId":    comicID,
+			"comicTitle": comicTitle,
+			"pageIndex":  pageIndex,
+			"pagePath":   pagePath,
+			"name":       name,
+			"note":       note,
+			"thumbnail":  thumbnail,
+			"createdAt":  createdAt,
+		})
+	}
+
+	return bookmarks, nil
+}
+
+// GetComicBookmarks 获取一本漫画的所有书签，按页码排序
+func (a *App) GetComicBookmarks(comicID int64) ([]map[string]interface{}, error) {
+	return a.queryBookmarks(`WHERE b.comic_id = ?`, `b.page_index, b.created_at`, comicID)
+}
+
+// GetAllBookmarks 获取整个漫画库的书签，最新添加的在前
+func (a *App) GetAllBookmarks() ([]map[string]interface{}, error) {
+	return a.queryBookmarks("", `b.created_at DESC, b.id DESC`)
+}
@@ -0,0 +1,411 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SmartCollectionRule 智能合集的单条过滤规则
+// Field 可选: read, favorite, minRating, tag, series, title, fileType, group, year, addedWithinDays, readWithinDays
+type SmartCollectionRule struct {
+	Field  string `json:"field"`
+	Value  string `json:"value"`
+	Negate bool   `json:"negate"`
+}
+
+// SmartCollectionRules 智能合集的规则集合，Match 为 all（全部满足）或 any（任一满足）
+type SmartCollectionRules struct {
+	Match string                `json:"match"`
+	Rules []SmartCollectionRule `json:"rules"`
+}
+
+// createCollectionTables 创建合集表及合集与漫画的关联表
+func (a *App) createCollectionTables() error {
+	createCollectionTable := `
+	CREATE TABLE IF NOT EXISTS collections (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL,
+		is_smart INTEGER DEFAULT 0,
+		rules TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);`
+
+	createCollectionComicTable := `
+	CREATE TABLE IF NOT EXISTS collection_comics (
+		collection_id INTEGER NOT NULL,
+		comic_id INTEGER NOT NULL,
+		position INTEGER NOT NULL DEFAULT 0,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (collection_id, comic_id),
+		FOREIGN KEY (collection_id) REFERENCES collections (id),
+		FOREIGN KEY (comic_id) REFERENCES comics (id)
+	);`
+
+	_, err := a.db.Exec(createCollectionTable)
+	if err != nil {
+		return fmt.Errorf("创建collections表失败: %v", err)
+	}
+
+	_, err = a.db.Exec(createCollectionComicTable)
+	if err != nil {
+		return fmt.Errorf("创建collection_comics表失败: %v", err)
+	}
+
+	return nil
+}
+
+// buildSmartCollectionWhere 将智能合集规则转换为SQL条件，漫画表别名须为c
+func buildSmartCollectionWhere(rules SmartCollectionRules) (string, []interface{}, error) {
+	var conditions []string
+	var args []interface{}
+
+	for _, rule := range rules.Rules {
+		var condition string
+		value := strings.TrimSpace(rule.Value)
+
+		switch rule.Field {
+		case "read":
+			read, err := strconv.ParseBool(value)
+			if err != nil {
+				return "", nil, fmt.Errorf("无效的已读规则: %s", rule.Value)
+			}
+			condition = "c.is_read = ?"
+			args = append(args, read)
+		case "favorite":
+			favorite, err := strconv.ParseBool(value)
+			if err != nil {
+				return "", nil, fmt.Errorf("无效的收藏规则: %s", rule.Value)
+			}
+			condition = "c.is_favorite = ?"
+			args = append(args, favorite)
+		case "minRating":
+			rating, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return "", nil, fmt.Errorf("无效的评分规则: %s", rule.Value)
+			}
+			condition = "c.rating >= ?"
+			args = append(args, rating)
+		case "tag":
+			condition = "EXISTS (SELECT 1 FROM comic_tags ct JOIN tags t ON t.id = ct.tag_id WHERE ct.comic_id = c.id AND t.name = ?)"
+			args = append(args, value)
+		case "series":
+			condition = "EXISTS (SELECT 1 FROM series s WHERE s.id = c.series_id AND s.name LIKE ?)"
+			args = append(args, "%"+value+"%")
+		case "title":
+			condition = "c.title LIKE ?"
+			args = append(args, "%"+value+"%")
+		case "fileType":
+			condition = "c.file_type = ?"
+			args = append(args, value)
+		case "group":
+			condition = "c.scan_group = ?"
+			args = append(args, value)
+		case "year":
+			year, err := strconv.Atoi(value)
+			if err != nil {
+				return "", nil, fmt.Errorf("无效的年份规则: %s", rule.Value)
+			}
+			condition = "c.release_year = ?"
+			args = append(args, year)
+		case "addedWithinDays", "readWithinDays":
+			days, err := strconv.Atoi(value)
+			if err != nil || days < 0 {
+				return "", nil, fmt.Errorf("无效的天数规则: %s", rule.Value)
+			}
+			column := "c.created_at"
+			if rule.Field == "readWithinDays" {
+				column = "c.last_read_at"
+			}
+			// created_at 由SQLite按UTC写入，last_read_at 带时区偏移，统一用datetime()换算为UTC再比较
+			condition = "datetime(" + column + ") >= datetime('now', ?)"
+			args = append(args, fmt.Sprintf("-%d days", days))
+		default:
+			return "", nil, fmt.Errorf("不支持的规则字段: %s", rule.Field)
+		}
+
+		if rule.Negate {
+			condition = "NOT (" + condition + ")"
+		}
+		conditions = append(conditions, condition)
+	}
+
+	if len(conditions) == 0 {
+		return "1 = 1", nil, nil
+	}
+
+	joiner := " AND "
+	if rules.Match == "any" {
+		joiner = " OR "
+	}
+
+	return "(" + strings.Join(conditions, joiner) + ")", args, nil
+}
+
+// CreateCollection 创建手动排序的合集
+func (a *App) CreateCollection(name string) (int64, error) {
+	if a.db == nil {
+		return 0, fmt.Errorf("数据库未初始化")
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return 0, fmt.Errorf("合集名不能为空")
+	}
+
+	result, err := a.db.Exec(`INSERT INTO collections (name, is_smart) VALUES (?, 0)`, name)
+	if err != nil {
+		return 0, fmt.Errorf("创建合集失败: %v", err)
+	}
+
+	return result.LastInsertId()
+}
+
+// CreateSmartCollection 创建由过滤规则定义的智能合集
+func (a *App) CreateSmartCollection(name string, rules SmartCollectionRules) (int64, error) {
+	if a.db == nil {
+		return 0, fmt.Errorf("数据库未初始化")
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return 0, fmt.Errorf("合集名不能为空")
+	}
+
+	// 保存前先校验规则
+	if _, _, err := buildSmartCollectionWhere(rules); err != nil {
+		return 0, err
+	}
+
+	data, err := json.Marshal(rules)
+	if err != nil {
+		return 0, fmt.Errorf("序列化合集规则失败: %v", err)
+	}
+
+	result, err := a.db.Exec(`INSERT INTO collections (name, is_smart, rules) VALUES (?, 1, ?)`, name, string(data))
+	if err != nil {
+		return 0, fmt.Errorf("创建智能合集失败: %v", err)
+	}
+
+	return result.LastInsertId()
+}
+
+// UpdateSmartCollectionRules 修改智能合集的过滤规则
+func (a *App) UpdateSmartCollectionRules(collectionID int64, rules SmartCollectionRules) error {
+	if a.db == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+
+	if _, _, err := buildSmartCollectionWhere(rules); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(rules)
+	if err != nil {
+		return fmt.Errorf("序列化合集规则失败: %v", err)
+	}
+
+	_, err = a.db.Exec(`UPDATE collections SET rules = ?, updated_at = ? WHERE id = ? AND is_smart = 1`,
+		string(data), time.Now(), collectionID)
+	if err != nil {
+		return fmt.Errorf("更新合集规则失败: %v", err)
+	}
+
+	return nil
+}
+
+// RenameCollection 重命名合集
+func (a *App) RenameCollection(collectionID int64, name string) error {
+	if a.db == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return fmt.Errorf("合集名不能为空")
+	}
+
+	_, err := a.db.Exec(`UPDATE collections SET name = ?, updated_at = ? WHERE id = ?`, name, time.Now(), collectionID)
+	if err != nil {
+		return fmt.Errorf("重命名合集失败: %v", err)
+	}
+
+	return nil
+}
+
+// DeleteCollection 删除合集，不会删除其中的漫画
+func (a *App) DeleteCollection(collectionID int64) error {
+	if a.db == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+
+	_, err := a.db.Exec(`DELETE FROM collection_comics WHERE collection_id = ?`, collectionID)
+	if err != nil {
+		return fmt.Errorf("删除合集关联失败: %v", err)
+	}
+
+	_, err = a.db.Exec(`DELETE FROM collections WHERE id = ?`, collectionID)
+	if err != nil {
+		return fmt.Errorf("删除合集失败: %v", err)
+	}
+
+	return nil
+}
+
+// GetCollections 获取所有合集，手动合集附带漫画数量
+func (a *App) GetCollections() ([]map[string]interface{}, error) {
+	if a.db == nil {
+		return nil, fmt.Errorf("数据库未初始化")
+	}
+
+	query := `
+	SELECT col.id, col.name, col.is_smart, COALESCE(col.rules, ''), COUNT(cc.comic_id), col.created_at, col.updated_at
+	FROM collections col
+	LEFT JOIN collection_comics cc ON cc.collection_id = col.id
+	GROUP BY col.id
+	ORDER BY col.name COLLATE NATURAL_ORDER`
+
+	rows, err := a.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("查询合集失败: %v", err)
+	}
+	defer rows.Close()
+
+	var collections []map[string]interface{}
+	for rows.Next() {
+		var id, comicCount int64
+		var name, rulesData, createdAt, updatedAt string
+		var isSmart bool
+
+		if err := rows.Scan(&id, &name, &isSmart, &rulesData, &comicCount, &createdAt, &updatedAt); err != nil {
+			continue
+		}
+
+		collection := map[string]interface{}{
+			"id":         id,
+			"name":       name,
+			"isSmart":    isSmart,
+			"comicCount": comicCount,
+			"createdAt":  createdAt,
+			"updatedAt":  updatedAt,
+		}
+		if isSmart {
+			var rules SmartCollectionRules
+			if err := json.Unmarshal([]byte(rulesData), &rules); err == nil {
+				collection["rules"] = rules
+			}
+		}
+		collections = append(collections, collection)
+	}
+
+	return collections, nil
+}
+
+// AddComicToCollection 将漫画添加到手动合集末尾
+func (a *App) AddComicToCollection(collectionID int64, comicID int64) error {
+	if a.db == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+
+	query := `
+	INSERT OR IGNORE INTO collection_comics (collection_id, comic_id, position)
+	SELECT ?, ?, COALESCE(MAX(position), -1) + 1 FROM collection_comics WHERE collection_id = ?`
+
+	_, err := a.db.Exec(query, collectionID, comicID, collectionID)
+	if err != nil {
+		return fmt.Errorf("添加漫画到合集失败: %v", err)
+	}
+
+	return nil
+}
+
+// RemoveComicFromCollection 从手动合集中移除漫画
+func (a *App) RemoveComicFromCollection(collectionID int64, comicID int64) error {
+	if a.db == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+
+	_, err := a.db.Exec(`DELETE FROM collection_comics WHERE collection_id = ? AND comic_id = ?`, collectionID, comicID)
+	if err != nil {
+		return fmt.Errorf("从合集移除漫画失败: %v", err)
+	}
+
+	return nil
+}
+
+// ReorderCollection 按给定的漫画ID顺序重新排列手动合集
+func (a *App) ReorderCollection(collectionID int64, comicIDs []int64) error {
+	if a.db == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+
+	tx, err := a.db.Begin()
+	if err != nil {
+		return fmt.Errorf("开启事务失败: %v", err)
+	}
+	defer tx.Rollback()
+
+	for position, comicID := range comicIDs {
+		_, err := tx.Exec(`UPDATE collection_comics SET position = ? WHERE collection_id = ? AND comic_id = ?`,
+			position, collectionID, comicID)
+		if err != nil {
+			return fmt.Errorf("更新合集顺序失败: %v", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交事务失败: %v", err)
+	}
+
+	return nil
+}
+
+// GetCollectionComics 获取合集中的漫画：手动合集按保存的顺序，智能合集实时按规则查询
+func (a *App) GetCollectionComics(collectionID int64) ([]map[string]interface{}, error) {
+	if a.db == nil {
+		return nil, fmt.Errorf("数据库未初始化")
+	}
+
+	var isSmart bool
+	var rulesData string
+	err := a.db.QueryRow(`SELECT is_smart, COALESCE(rules, '') FROM collections WHERE id = ?`, collectionID).Scan(&isSmart, &rulesData)
+	if err != nil {
+		return nil, fmt.Errorf("查询合集失败: %v", err)
+	}
+
+	if !isSmart {
+		query := `SELECT ` + comicSelectColumns + `
+				  FROM comics c
+				  JOIN collection_comics cc ON cc.comic_id = c.id
+				  WHERE cc.collection_id = ?
+				  ORDER BY cc.position, cc.created_at`
+		return a.queryComics(query, collectionID)
+	}
+
+	var rules SmartCollectionRules
+	if err := json.Unmarshal([]byte(rulesData), &rules); err != nil {
+		return nil, fmt.Errorf("解析合集规则失败: %v", err)
+	}
+
+	where, args, err := buildSmartCollectionWhere(rules)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `SELECT ` + comicSelectColumns + ` FROM comics c WHERE ` + where + ` ORDER BY c.updated_at DESC`
+	return a.queryComics(query, args...)
+}
+
+// PreviewSmartCollection 按规则查询漫画但不保存，用于编辑智能合集时预览
+func (a *App) PreviewSmartCollection(rules SmartCollectionRules) ([]map[string]interface{}, error) {
+	where, args, err := buildSmartCollectionWhere(rules)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `SELECT ` + comicSelectColumns + ` FROM comics c WHERE ` + where + ` ORDER BY c.updated_at DESC`
+	return a.queryComics(query, args...)
+}
@@ -0,0 +1,330 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// comicInfoFileName ComicInfo.xml 在压缩包中的标准文件名
+const comicInfoFileName = "ComicInfo.xml"
+
+// ComicInfo 对应 ComicRack 的 ComicInfo.xml 元数据
+type ComicInfo struct {
+	XMLName     xml.Name `xml:"ComicInfo" json:"-"`
+	Title       string   `xml:"Title,omitempty" json:"title"`
+	Series      string   `xml:"Series,omitempty" json:"series"`
+	Number      string   `xml:"Number,omitempty" json:"number"`
+	Count       int      `xml:"Count,omitempty" json:"count"`
+	Volume      int      `xml:"Volume,omitempty" json:"volume"`
+	Summary     string   `xml:"Summary,omitempty" json:"summary"`
+	Notes       string   `xml:"Notes,omitempty" json:"notes"`
+	Year        int      `xml:"Year,omitempty" json:"year"`
+	Month       int      `xml:"Month,omitempty" json:"month"`
+	Day         int      `xml:"Day,omitempty" json:"day"`
+	Writer      string   `xml:"Writer,omitempty" json:"writer"`
+	Penciller   string   `xml:"Penciller,omitempty" json:"penciller"`
+	Translator  string   `xml:"Translator,omitempty" json:"translator"`
+	Publisher   string   `xml:"Publisher,omitempty" json:"publisher"`
+	Genre       string   `xml:"Genre,omitempty" json:"genre"`
+	Tags        string   `xml:"Tags,omitempty" json:"tags"`
+	Web         string   `xml:"Web,omitempty" json:"web"`
+	PageCount   int      `xml:"PageCount,omitempty" json:"pageCount"`
+	LanguageISO string   `xml:"LanguageISO,omitempty" json:"languageISO"`
+	Manga       string   `xml:"Manga,omitempty" json:"manga"`
+	AgeRating   string   `xml:"AgeRating,omitempty" json:"ageRating"`
+
+	// Extra 未建模的元素（Inker、Characters、Pages等），写回时原样保留
+	Extra []comicInfoElement `xml:",any" json:"-"`
+}
+
+// comicInfoElement ComicInfo.xml中未建模的元素，保留属性和原始内容
+type comicInfoElement struct {
+	XMLName xml.Name
+	Attrs   []xml.Attr `xml:",any,attr"`
+	Content []byte     `xml:",innerxml"`
+}
+
+// ComicInfoUpdate 批量写回时单本漫画的元数据
+type ComicInfoUpdate struct {
+	ComicID int64     `json:"comicId"`
+	Info    ComicInfo `json:"info"`
+}
+
+// isComicInfoEntry 判断zip条目是否为根目录下的ComicInfo.xml
+func isComicInfoEntry(name string) bool {
+	return strings.EqualFold(name, comicInfoFileName)
+}
+
+// marshalComicInfo 将元数据序列化为带XML声明的ComicInfo.xml内容
+func marshalComicInfo(info *ComicInfo) ([]byte, error) {
+	data, err := xml.MarshalIndent(info, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("序列化ComicInfo失败: %v", err)
+	}
+	return append([]byte(xml.Header), append(data, '\n')...), nil
+}
+
+// readComicInfoFromZip 读取zip文件中的ComicInfo.xml，不存在时返回nil
+func (a *App) readComicInfoFromZip(zipPath string) (*ComicInfo, error) {
+	reader, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return nil, fmt.Errorf("打开zip文件失败: %v", err)
+	}
+	defer reader.Close()
+
+	for _, file := range reader.File {
+		if isComicInfoEntry(file.Name) {
+			return readComicInfoEntry(file)
+		}
+	}
+
+	return nil, nil
+}
+
+// readComicInfoEntry 解析zip中的ComicInfo.xml条目
+func readComicInfoEntry(file *zip.File) (*ComicInfo, error) {
+	rc, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("打开ComicInfo.xml失败: %v", err)
+	}
+	data, err := io.ReadAll(rc)
+	rc.Close()
+	if err != nil {
+		return nil, fmt.Errorf("读取ComicInfo.xml失败: %v", err)
+	}
+
+	var info ComicInfo
+	if err := xml.Unmarshal(data, &info); err != nil {
+		return nil, fmt.Errorf("解析ComicInfo.xml失败: %v", err)
+	}
+	return &info, nil
+}
+
+// getComicPath 根据漫画ID获取文件路径和类型
+func (a *App) getComicPath(comicID int64) (string, string, error) {
+	if a.db == nil {
+		return "", "", fmt.Errorf("数据库未初始化")
+	}
+
+	var filePath, fileType string
+	err := a.db.QueryRow(`SELECT file_path, file_type FROM comics WHERE id = ?`, comicID).Scan(&filePath, &fileType)
+	if err != nil {
+		return "", "", fmt.Errorf("查询漫画信息失败: %v", err)
+	}
+
+	return filePath, fileType, nil
+}
+
+// rewriteComicInfo 用新的ComicInfo.xml重写zip文件
+// 其他条目以原始压缩数据直接复制，不会重新压缩；写入临时文件后再原子替换原文件
+func (a *App) rewriteComicInfo(zipPath string, info *ComicInfo, dryRun bool) (map[string]interface{}, error) {
+	reader, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return nil, fmt.Errorf("打开zip文件失败: %v", err)
+	}
+	defer reader.Close()
+
+	hadComicInfo := false
+	for _, file := range reader.File {
+		if !isComicInfoEntry(file.Name) {
+			continue
+		}
+		hadComicInfo = true
+
+		// 前端只编辑已建模的字段，其余元素从原文件中带过来，避免写回时丢失；原文件损坏时直接覆盖
+		if len(info.Extra) == 0 {
+			existing, err := readComicInfoEntry(file)
+			if err != nil {
+				fmt.Printf("原ComicInfo.xml无法解析，将直接覆盖 %s: %v\n", zipPath, err)
+				break
+			}
+			merged := *info
+			merged.Extra = existing.Extra
+			info = &merged
+		}
+		break
+	}
+
+	xmlData, err := marshalComicInfo(info)
+	if err != nil {
+		return nil, err
+	}
+
+	result := map[string]interface{}{
+		"filePath":     zipPath,
+		"dryRun":       dryRun,
+		"hadComicInfo": hadComicInfo,
+		"entries":      len(reader.File),
+		"comicInfo":    string(xmlData),
+		"written":      false,
+	}
+
+	if dryRun {
+		return result, nil
+	}
+
+	fileInfo, err := os.Stat(zipPath)
+	if err != nil {
+		return nil, fmt.Errorf("获取文件信息失败: %v", err)
+	}
+
+	// 临时文件放在同一目录下，保证rename是原子操作
+	tmpFile, err := os.CreateTemp(filepath.Dir(zipPath), "."+filepath.Base(zipPath)+".*.tmp")
+	if err != nil {
+		return nil, fmt.Errorf("创建临时文件失败: %v", err)
+	}
+	tmpPath := tmpFile.Name()
+	committed := false
+	defer func() {
+		if !committed {
+			tmpFile.Close()
+			os.Remove(tmpPath)
+		}
+	}()
+
+	writer := zip.NewWriter(tmpFile)
+	if err := writer.SetComment(reader.Comment); err != nil {
+		return nil, fmt.Errorf("写入zip注释失败: %v", err)
+	}
+
+	writeComicInfo := func() error {
+		header := &zip.FileHeader{
+			Name:     comicInfoFileName,
+			Method:   zip.Deflate,
+			Modified: time.Now(),
+		}
+		w, err := writer.CreateHeader(header)
+		if err != nil {
+			return fmt.Errorf("创建ComicInfo.xml条目失败: %v", err)
+		}
+		if _, err := io.Copy(w, bytes.NewReader(xmlData)); err != nil {
+			return fmt.Errorf("写入ComicInfo.xml失败: %v", err)
+		}
+		return nil
+	}
+
+	// 原来已有ComicInfo.xml时保持其在压缩包中的位置
+	for _, file := range reader.File {
+		if isComicInfoEntry(file.Name) {
+			if err := writeComicInfo(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if err := writer.Copy(file); err != nil {
+			return nil, fmt.Errorf("复制条目 %s 失败: %v", file.Name, err)
+		}
+	}
+	if !hadComicInfo {
+		if err := writeComicInfo(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("写入zip文件失败: %v", err)
+	}
+	if err := tmpFile.Sync(); err != nil {
+		return nil, fmt.Errorf("同步临时文件失败: %v", err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		return nil, fmt.Errorf("关闭临时文件失败: %v", err)
+	}
+	if err := os.Chmod(tmpPath, fileInfo.Mode().Perm()); err != nil {
+		return nil, fmt.Errorf("设置文件权限失败: %v", err)
+	}
+
+	// Windows下必须先关闭原文件才能替换
+	reader.Close()
+	if err := os.Rename(tmpPath, zipPath); err != nil {
+		return nil, fmt.Errorf("替换原文件失败: %v", err)
+	}
+	committed = true
+
+	newInfo, err := os.Stat(zipPath)
+	if err == nil {
+		result["fileSize"] = newInfo.Size()
+	}
+	result["written"] = true
+
+	fmt.Printf("已写回ComicInfo.xml: %s\n", zipPath)
+	return result, nil
+}
+
+// GetComicInfo 读取漫画压缩包中的ComicInfo.xml
+func (a *App) GetComicInfo(comicID int64) (*ComicInfo, error) {
+	filePath, fileType, err := a.getComicPath(comicID)
+	if err != nil {
+		return nil, err
+	}
+	if fileType != "zip" {
+		return nil, nil
+	}
+
+	return a.readComicInfoFromZip(filePath)
+}
+
+// WriteComicInfo 将编辑后的元数据写回漫画压缩包，dryRun为true时只返回将要写入的内容
+func (a *App) WriteComicInfo(comicID int64, info ComicInfo, dryRun bool) (map[string]interface{}, error) {
+	filePath, fileType, err := a.getComicPath(comicID)
+	if err != nil {
+		return nil, err
+	}
+	if fileType != "zip" {
+		return nil, fmt.Errorf("仅支持写回zip/cbz文件: %s", filePath)
+	}
+	if !dryRun {
+		unlock, err := a.lockComic(comicID)
+		if err != nil {
+			return nil, err
+		}
+		defer unlock()
+	}
+
+	result, err := a.rewriteComicInfo(filePath, &info, dryRun)
+	if err != nil {
+		return nil, err
+	}
+	result["comicId"] = comicID
+
+	if written, _ := result["written"].(bool); written {
+		_, err = a.db.Exec(`UPDATE comics SET file_size = ?, updated_at = ? WHERE id = ?`,
+			result["fileSize"], time.Now(), comicID)
+		if err != nil {
+			return nil, fmt.Errorf("更新漫画信息失败: %v", err)
+		}
+	}
+
+	return result, nil
+}
+
+// WriteComicInfoBatch 批量写回多本漫画的元数据，单本失败不影响其他漫画
+func (a *App) WriteComicInfoBatch(updates []ComicInfoUpdate, dryRun bool) ([]map[string]interface{}, error) {
+	if a.db == nil {
+		return nil, fmt.Errorf("数据库未初始化")
+	}
+
+	var results []map[string]interface{}
+	for _, update := range updates {
+		result, err := a.WriteComicInfo(update.ComicID, update.Info, dryRun)
+		if err != nil {
+			fmt.Printf("写回元数据失败 %d: %v\n", update.ComicID, err)
+			result = map[string]interface{}{
+				"comicId": update.ComicID,
+				"dryRun":  dryRun,
+				"written": false,
+				"error":   err.Error(),
+			}
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
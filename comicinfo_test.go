@@ -0,0 +1,136 @@
+package main
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeTestZip 生成包含一页图片和指定ComicInfo.xml的zip，comicInfo为空时不写入
+func writeTestZip(t *testing.T, comicInfo string) string {
+	t.Helper()
+	zipPath := filepath.Join(t.TempDir(), "comic.cbz")
+	file, err := os.Create(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writer := zip.NewWriter(file)
+	entries := [][2]string{{"001.jpg", "page"}}
+	if comicInfo != "" {
+		entries = append(entries, [2]string{comicInfoFileName, comicInfo})
+	}
+	for _, entry := range entries {
+		w, err := writer.Create(entry[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(entry[1]))
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+	return zipPath
+}
+
+// TestRewriteComicInfoExtra 写回时保留未建模的元素，已建模的字段以传入的为准
+func TestRewriteComicInfoExtra(t *testing.T) {
+	const original = `<?xml version="1.0" encoding="utf-8"?>
+<ComicInfo xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance">
+  <Title>Old Title</Title>
+  <Series>Series</Series>
+  <Inker>Someone</Inker>
+  <Characters>Alice, Bob</Characters>
+  <Pages>
+    <Page Image="0" Type="FrontCover" DoublePage="false" />
+    <Page Image="1" />
+  </Pages>
+</ComicInfo>`
+
+	tests := []struct {
+		name     string
+		existing string
+		info     ComicInfo
+		dryRun   bool
+		contains []string
+		excludes []string
+	}{
+		{
+			name:     "unmodelled elements kept",
+			existing: original,
+			info:     ComicInfo{Title: "New Title"},
+			contains: []string{"<Title>New Title</Title>", "<Inker>Someone</Inker>", "<Characters>Alice, Bob</Characters>", `<Page Image="0" Type="FrontCover" DoublePage="false"`, `<Page Image="1"`},
+			excludes: []string{"Old Title", "<Series>"},
+		},
+		{
+			name:     "dry run previews merged result",
+			existing: original,
+			info:     ComicInfo{Title: "New Title"},
+			dryRun:   true,
+			contains: []string{"<Title>New Title</Title>", "<Inker>Someone</Inker>"},
+		},
+		{
+			name:     "caller extra replaces existing",
+			existing: original,
+			info:     ComicInfo{Title: "New Title", Extra: []comicInfoElement{{XMLName: xml.Name{Local: "Colorist"}, Content: []byte("Carol")}}},
+			contains: []string{"<Colorist>Carol</Colorist>"},
+			excludes: []string{"<Inker>", "<Pages>"},
+		},
+		{
+			name:     "corrupt existing overwritten",
+			existing: "<ComicInfo><Title>broken",
+			info:     ComicInfo{Title: "New Title"},
+			contains: []string{"<Title>New Title</Title>"},
+			excludes: []string{"broken"},
+		},
+		{
+			name:     "missing ComicInfo added",
+			info:     ComicInfo{Title: "New Title"},
+			contains: []string{"<Title>New Title</Title>"},
+		},
+	}
+
+	a := &App{}
+	for _, tt := range tests {
+		zipPath := writeTestZip(t, tt.existing)
+		result, err := a.rewriteComicInfo(zipPath, &tt.info, tt.dryRun)
+		if err != nil {
+			t.Fatalf("%s: rewriteComicInfo: %v", tt.name, err)
+		}
+		if result["written"] != !tt.dryRun {
+			t.Errorf("%s: written = %v, want %v", tt.name, result["written"], !tt.dryRun)
+		}
+
+		preview := result["comicInfo"].(string)
+		written := preview
+		if !tt.dryRun {
+			reader, err := zip.OpenReader(zipPath)
+			if err != nil {
+				t.Fatalf("%s: open rewritten zip: %v", tt.name, err)
+			}
+			data, err := readZipFile(&reader.Reader, comicInfoFileName)
+			reader.Close()
+			if err != nil {
+				t.Fatalf("%s: read ComicInfo.xml: %v", tt.name, err)
+			}
+			written = string(data)
+			if written != preview {
+				t.Errorf("%s: written ComicInfo.xml differs from preview", tt.name)
+			}
+		}
+
+		for _, s := range tt.contains {
+			if !strings.Contains(written, s) {
+				t.Errorf("%s: ComicInfo.xml missing %q:\n%s", tt.name, s, written)
+			}
+		}
+		for _, s := range tt.excludes {
+			if strings.Contains(written, s) {
+				t.Errorf("%s: ComicInfo.xml should not contain %q:\n%s", tt.name, s, written)
+			}
+		}
+	}
+}
@@ -0,0 +1,739 @@
+package main
+
+import (
+	"archive/zip"
+	"bufio"
+	"database/sql"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ConvertOptions 转换为CBZ的选项
+type ConvertOptions struct {
+	EmbedComicInfo bool   `json:"embedComicInfo"` // 写入ComicInfo.xml
+	ReplaceSource  bool   `json:"replaceSource"`  // 转换成功后删除源文件，并让书库中的记录指向新文件
+	OutputPath     string `json:"outputPath"`     // 输出路径，为空时与源文件同目录同名
+}
+
+// externalExtractors 各格式可用的外部解包工具，按顺序尝试第一个存在的命令
+// {in} 和 {out} 分别替换为源文件和输出目录
+var externalExtractors = map[string][][]string{
+	"rar": {
+		{"unrar", "x", "-o+", "-inul", "{in}", "{out}" + string(filepath.Separator)},
+		{"7z", "x", "-y", "-o{out}", "{in}"},
+		{"7zz", "x", "-y", "-o{out}", "{in}"},
+		{"bsdtar", "-xf", "{in}", "-C", "{out}"},
+	},
+	"7z": {
+		{"7z", "x", "-y", "-o{out}", "{in}"},
+		{"7zz", "x", "-y", "-o{out}", "{in}"},
+		{"7za", "x", "-y", "-o{out}", "{in}"},
+		{"bsdtar", "-xf", "{in}", "-C", "{out}"},
+	},
+	// PDF按页渲染，每页一张图片
+	"pdf": {
+		{"mutool", "draw", "-q", "-r", "150", "-o", filepath.Join("{out}", "page-%d.png"), "{in}"},
+		{"pdftoppm", "-png", "-r", "150", "{in}", filepath.Join("{out}", "page")},
+	},
+	// 直接提取PDF内嵌的原始图片，只在每页恰好一张图片时使用，见 pdfHasOneImagePerPage
+	"pdf-images": {
+		{"pdfimages", "-all", "{in}", filepath.Join("{out}", "page")},
+	},
+}
+
+// pdfImageEncodings pdfimages -all 能输出为普通图片文件的编码，jpx、jbig2、ccitt会输出为浏览器无法显示的原始数据
+var pdfImageEncodings = map[string]bool{
+	"image": true,
+	"jpeg":  true,
+}
+
+// convertSourceFormats 可以转换为CBZ的源文件扩展名
+var convertSourceFormats = map[string]string{
+	".zip":  "zip",
+	".cbz":  "zip",
+	".rar":  "rar",
+	".cbr":  "rar",
+	".7z":   "7z",
+	".cb7":  "7z",
+	".pdf":  "pdf",
+	".epub": "epub",
+}
+
+// mimeExtensions 没有扩展名或扩展名不可识别时，按文件头确定输出的扩展名
+var mimeExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+	"image/tiff": ".tif",
+	"image/bmp":  ".bmp",
+	"image/avif": ".avif",
+	"image/jxl":  ".jxl",
+	"image/heif": ".heic",
+}
+
+// convertPage 待写入CBZ的一页
+type convertPage struct {
+	name string
+	open func() (io.ReadCloser, error)
+}
+
+// convertSourceFormat 判断源文件的格式
+func convertSourceFormat(sourcePath string) (string, error) {
+	info, err := os.Stat(sourcePath)
+	if err != nil {
+		return "", fmt.Errorf("源文件不存在: %v", err)
+	}
+	if info.IsDir() {
+		return "folder", nil
+	}
+	format, ok := convertSourceFormats[strings.ToLower(filepath.Ext(sourcePath))]
+	if !ok {
+		return "", fmt.Errorf("不支持转换的文件格式: %s", filepath.Ext(sourcePath))
+	}
+	return format, nil
+}
+
+// extractWithExternalTool 用外部工具把源文件解包到目录中
+func extractWithExternalTool(format, inPath, outDir string) error {
+	var names []string
+	for _, command := range externalExtractors[format] {
+		names = append(names, command[0])
+		binary, err := exec.LookPath(command[0])
+		if err != nil {
+			continue
+		}
+
+		args := make([]string, 0, len(command)-1)
+		for _, arg := range command[1:] {
+			arg = strings.ReplaceAll(arg, "{in}", inPath)
+			arg = strings.ReplaceAll(arg, "{out}", outDir)
+			args = append(args, arg)
+		}
+
+		output, err := exec.Command(binary, args...).CombinedOutput()
+		if err != nil {
+			fmt.Printf("%s 解包失败: %v %s\n", command[0], err, strings.TrimSpace(string(output)))
+			continue
+		}
+		return nil
+	}
+
+	return fmt.Errorf("没有可用的%s解包工具，请安装: %s", format, strings.Join(names, " / "))
+}
+
+// pdfHasOneImagePerPage 检查PDF是否每页恰好一张可直接提取的图片（扫描版漫画的常见情况）
+// 含有蒙版、分块或多张图片的页面直接提取会得到多余或乱序的图片，需要按页渲染
+func pdfHasOneImagePerPage(pdfPath string) bool {
+	pdfinfo, err := exec.LookPath("pdfinfo")
+	if err != nil {
+		return false
+	}
+	pdfimages, err := exec.LookPath("pdfimages")
+	if err != nil {
+		return false
+	}
+
+	output, err := exec.Command(pdfinfo, pdfPath).Output()
+	if err != nil {
+		return false
+	}
+	pageCount := 0
+	for _, line := range strings.Split(string(output), "\n") {
+		if value, ok := strings.CutPrefix(line, "Pages:"); ok {
+			pageCount, _ = strconv.Atoi(strings.TrimSpace(value))
+		}
+	}
+	if pageCount == 0 {
+		return false
+	}
+
+	// pdfimages -list 的前两行为表头，之后每行：page num type width height color comp bpc enc ...
+	output, err = exec.Command(pdfimages, "-list", pdfPath).Output()
+	if err != nil {
+		return false
+	}
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	if len(lines) < 2 {
+		return false
+	}
+	images := make(map[int]int)
+	for _, line := range lines[2:] {
+		fields := strings.Fields(line)
+		if len(fields) < 9 || fields[2] != "image" || !pdfImageEncodings[fields[8]] {
+			return false
+		}
+		page, err := strconv.Atoi(fields[0])
+		if err != nil {
+			return false
+		}
+		images[page]++
+	}
+	for page := 1; page <= pageCount; page++ {
+		if images[page] != 1 {
+			return false
+		}
+	}
+	return len(images) == pageCount
+}
+
+// folderConvertPages 按自然顺序列出文件夹中的页面，同时返回被忽略规则排除的图片
+func (a *App) folderConvertPages(folderPath string) ([]convertPage, []string, error) {
+	pages, err := a.listFolderPages(folderPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	all, err := a.scanFolderPages(folderPath, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	result := make([]convertPage, 0, len(pages))
+	for _, page := range pages {
+		filePath := page.Path
+		result = append(result, convertPage{name: page.Name, open: func() (io.ReadCloser, error) { return os.Open(filePath) }})
+	}
+	return result, a.skippedImages(pages, all), nil
+}
+
+// skippedImages 找出all中未出现在pages里、且文件头确实是图片的页面
+// 忽略规则和招募页过滤只影响显示，转换时要据此判断替换源文件是否会丢失页面
+func (a *App) skippedImages(pages, all []comicPage) []string {
+	kept := make(map[string]bool, len(pages))
+	for _, page := range pages {
+		kept[page.Name] = true
+	}
+
+	skipped := []string{}
+	for _, page := range all {
+		if kept[page.Name] {
+			continue
+		}
+		rc, err := a.openPage(page)
+		if err != nil {
+			continue
+		}
+		header := readHeader(rc)
+		rc.Close()
+		if sniffImageType(header) != "" {
+			skipped = append(skipped, page.Name)
+		}
+	}
+	return skipped
+}
+
+// epubContainer META-INF/container.xml
+type epubContainer struct {
+	Rootfiles []struct {
+		FullPath string `xml:"full-path,attr"`
+	} `xml:"rootfiles>rootfile"`
+}
+
+// epubPackage OPF文件中转换需要的部分
+type epubPackage struct {
+	Metadata struct {
+		Title     string `xml:"title"`
+		Creator   string `xml:"creator"`
+		Publisher string `xml:"publisher"`
+		Language  string `xml:"language"`
+	} `xml:"metadata"`
+	Manifest []struct {
+		ID        string `xml:"id,attr"`
+		Href      string `xml:"href,attr"`
+		MediaType string `xml:"media-type,attr"`
+	} `xml:"manifest>item"`
+	Spine []struct {
+		IDRef string `xml:"idref,attr"`
+	} `xml:"spine>itemref"`
+}
+
+// epubImageRefPattern XHTML中引用图片的 img src 和 svg image href
+var epubImageRefPattern = regexp.MustCompile(`(?is)<(?:img|image)\b[^>]*?\s(?:src|xlink:href|href)\s*=\s*["']([^"']+)["']`)
+
+// readZipFile 读取zip中的整个条目
+func readZipFile(reader *zip.Reader, name string) ([]byte, error) {
+	file := findZipEntry(reader, name)
+	if file == nil {
+		return nil, fmt.Errorf("找不到条目: %s", name)
+	}
+	rc, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+// resolveEpubHref 把相对于baseFile的引用解析为zip中的条目名
+func resolveEpubHref(baseFile, href string) string {
+	href = strings.SplitN(href, "#", 2)[0]
+	if unescaped, err := url.PathUnescape(href); err == nil {
+		href = unescaped
+	}
+	return strings.TrimPrefix(path.Join(path.Dir(baseFile), href), "/")
+}
+
+// epubConvertPages 按阅读顺序列出EPUB中的图片：依次解析spine中每个页面引用的图片，
+// 没有找到时退回到manifest中的全部图片。返回的元数据用于生成ComicInfo.xml
+func (a *App) epubConvertPages(epubPath string) ([]convertPage, *ComicInfo, io.Closer, error) {
+	reader, err := zip.OpenReader(epubPath)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("打开epub文件失败: %v", err)
+	}
+	fail := func(err error) ([]convertPage, *ComicInfo, io.Closer, error) {
+		reader.Close()
+		return nil, nil, nil, err
+	}
+
+	data, err := readZipFile(&reader.Reader, "META-INF/container.xml")
+	if err != nil {
+		return fail(fmt.Errorf("读取epub容器信息失败: %v", err))
+	}
+	var container epubContainer
+	if err := xml.Unmarshal(data, &container); err != nil || len(container.Rootfiles) == 0 {
+		return fail(fmt.Errorf("解析epub容器信息失败: %v", err))
+	}
+	opfPath := container.Rootfiles[0].FullPath
+
+	data, err = readZipFile(&reader.Reader, opfPath)
+	if err != nil {
+		return fail(fmt.Errorf("读取epub目录失败: %v", err))
+	}
+	var pkg epubPackage
+	if err := xml.Unmarshal(data, &pkg); err != nil {
+		return fail(fmt.Errorf("解析epub目录失败: %v", err))
+	}
+
+	hrefs := make(map[string]string)
+	for _, item := range pkg.Manifest {
+		hrefs[item.ID] = resolveEpubHref(opfPath, item.Href)
+	}
+
+	var names []string
+	seen := make(map[string]bool)
+	addImage := func(name string) {
+		if seen[name] || findZipEntry(&reader.Reader, name) == nil {
+			return
+		}
+		seen[name] = true
+		names = append(names, name)
+	}
+	for _, ref := range pkg.Spine {
+		docPath, ok := hrefs[ref.IDRef]
+		if !ok {
+			continue
+		}
+		doc, err := readZipFile(&reader.Reader, docPath)
+		if err != nil {
+			continue
+		}
+		for _, match := range epubImageRefPattern.FindAllSubmatch(doc, -1) {
+			addImage(resolveEpubHref(docPath, string(match[1])))
+		}
+	}
+
+	if len(names) == 0 {
+		var images []string
+		for _, item := range pkg.Manifest {
+			if strings.HasPrefix(item.MediaType, "image/") {
+				images = append(images, hrefs[item.ID])
+			}
+		}
+		sort.Slice(images, func(i, j int) bool { return a.naturalSort(images[i], images[j]) })
+		for _, name := range images {
+			addImage(name)
+		}
+	}
+
+	pages := make([]convertPage, 0, len(names))
+	for _, name := range names {
+		file := findZipEntry(&reader.Reader, name)
+		pages = append(pages, convertPage{name: name, open: file.Open})
+	}
+
+	info := &ComicInfo{
+		Title:       strings.TrimSpace(pkg.Metadata.Title),
+		Writer:      strings.TrimSpace(pkg.Metadata.Creator),
+		Publisher:   strings.TrimSpace(pkg.Metadata.Publisher),
+		LanguageISO: strings.TrimSpace(pkg.Metadata.Language),
+	}
+	return pages, info, reader, nil
+}
+
+// collectConvertPages 按阅读顺序收集源文件中的页面，同时返回被忽略规则排除的图片，cleanup用于释放打开的文件和临时目录
+func (a *App) collectConvertPages(sourcePath, format string) ([]convertPage, []string, *ComicInfo, func(), error) {
+	switch format {
+	case "folder":
+		pages, skipped, err := a.folderConvertPages(sourcePath)
+		return pages, skipped, nil, func() {}, err
+	case "zip":
+		zipPages, err := a.listZipPages(sourcePath)
+		if err != nil {
+			return nil, nil, nil, nil, err
+		}
+		all, err := a.scanZipPages(sourcePath, nil)
+		if err != nil {
+			return nil, nil, nil, nil, err
+		}
+		pages := make([]convertPage, 0, len(zipPages))
+		for _, page := range zipPages {
+			page := page
+			pages = append(pages, convertPage{name: page.Name, open: func() (io.ReadCloser, error) { return a.openPage(page) }})
+		}
+		info, err := a.readComicInfoFromZip(sourcePath)
+		if err != nil {
+			fmt.Printf("读取ComicInfo.xml失败 %s: %v\n", sourcePath, err)
+		}
+		return pages, a.skippedImages(zipPages, all), info, func() {}, nil
+	case "epub":
+		pages, info, closer, err := a.epubConvertPages(sourcePath)
+		if err != nil {
+			return nil, nil, nil, nil, err
+		}
+		return pages, []string{}, info, func() { closer.Close() }, nil
+	default:
+		tmpDir, err := os.MkdirTemp("", "r-comic-convert-*")
+		if err != nil {
+			return nil, nil, nil, nil, fmt.Errorf("创建临时目录失败: %v", err)
+		}
+		cleanup := func() { os.RemoveAll(tmpDir) }
+		extracted := false
+		if format == "pdf" && pdfHasOneImagePerPage(sourcePath) {
+			// 每页一张图片时直接提取原图，避免重新渲染损失画质；失败时清掉已提取的部分再渲染
+			extracted = extractWithExternalTool("pdf-images", sourcePath, tmpDir) == nil
+			if !extracted {
+				os.RemoveAll(tmpDir)
+				if err := os.MkdirAll(tmpDir, 0755); err != nil {
+					return nil, nil, nil, nil, fmt.Errorf("创建临时目录失败: %v", err)
+				}
+			}
+		}
+		if !extracted {
+			if err := extractWithExternalTool(format, sourcePath, tmpDir); err != nil {
+				cleanup()
+				return nil, nil, nil, nil, err
+			}
+		}
+		pages, skipped, err := a.folderConvertPages(tmpDir)
+		if err != nil {
+			cleanup()
+			return nil, nil, nil, nil, err
+		}
+		return pages, skipped, nil, cleanup, nil
+	}
+}
+
+// convertedPageExtension 输出页面的扩展名：优先使用原扩展名，不可识别时按文件头判断
+func convertedPageExtension(name string, header []byte) string {
+	ext := strings.ToLower(path.Ext(name))
+	if imageExtensions[ext] {
+		if ext == ".jpeg" {
+			return ".jpg"
+		}
+		return ext
+	}
+	if sniffed, ok := mimeExtensions[sniffImageType(header)]; ok {
+		return sniffed
+	}
+	return ext
+}
+
+// comicInfoForConversion 生成写入CBZ的元数据：保留源文件已有的信息，缺少的从书库记录和文件名补全
+func (a *App) comicInfoForConversion(sourcePath, title string, info *ComicInfo, pageCount int) *ComicInfo {
+	result := &ComicInfo{}
+	if info != nil {
+		*result = *info
+	}
+
+	if result.Title == "" {
+		result.Title = title
+	}
+	if result.Series == "" {
+		series := a.detectSeries(sourcePath, "")
+		result.Series = series.Series
+		if result.Number == "" {
+			result.Number = series.Number
+		}
+		if volume, err := strconv.Atoi(series.Volume); err == nil && result.Volume == 0 {
+			result.Volume = volume
+		}
+	}
+	result.PageCount = pageCount
+	return result
+}
+
+// writeCBZ 把页面按顺序写入CBZ，文件名为0001.ext，图片本身已经压缩，只做存储
+// comicInfo为新生成的元数据，sourceComicInfo为源压缩包中原样复制的ComicInfo.xml，二者最多一个不为空
+// 写入输出路径同目录下的临时文件并返回其路径，由调用方校验后再改名
+func writeCBZ(outputPath string, pages []convertPage, comicInfo *ComicInfo, sourceComicInfo *zip.File) (string, error) {
+	tmpFile, err := os.CreateTemp(filepath.Dir(outputPath), "."+filepath.Base(outputPath)+".*.tmp")
+	if err != nil {
+		return "", fmt.Errorf("创建临时文件失败: %v", err)
+	}
+	tmpPath := tmpFile.Name()
+	committed := false
+	defer func() {
+		if !committed {
+			tmpFile.Close()
+			os.Remove(tmpPath)
+		}
+	}()
+
+	writer := zip.NewWriter(tmpFile)
+	modified := time.Now()
+	for i, page := range pages {
+		rc, err := page.open()
+		if err != nil {
+			return "", fmt.Errorf("打开页面 %s 失败: %v", page.name, err)
+		}
+		reader := bufio.NewReader(rc)
+		header, _ := reader.Peek(sniffHeaderSize)
+
+		w, err := writer.CreateHeader(&zip.FileHeader{
+			Name:     fmt.Sprintf("%04d%s", i+1, convertedPageExtension(page.name, header)),
+			Method:   zip.Store,
+			Modified: modified,
+		})
+		if err == nil {
+			_, err = io.Copy(w, reader)
+		}
+		rc.Close()
+		if err != nil {
+			return "", fmt.Errorf("写入页面 %s 失败: %v", page.name, err)
+		}
+	}
+
+	if comicInfo != nil {
+		data, err := marshalComicInfo(comicInfo)
+		if err != nil {
+			return "", err
+		}
+		w, err := writer.CreateHeader(&zip.FileHeader{Name: comicInfoFileName, Method: zip.Deflate, Modified: modified})
+		if err != nil {
+			return "", fmt.Errorf("创建ComicInfo.xml条目失败: %v", err)
+		}
+		if _, err := w.Write(data); err != nil {
+			return "", fmt.Errorf("写入ComicInfo.xml失败: %v", err)
+		}
+	}
+	if sourceComicInfo != nil {
+		if err := writer.Copy(sourceComicInfo); err != nil {
+			return "", fmt.Errorf("复制ComicInfo.xml失败: %v", err)
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return "", fmt.Errorf("写入zip文件失败: %v", err)
+	}
+	if err := tmpFile.Sync(); err != nil {
+		return "", fmt.Errorf("同步临时文件失败: %v", err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		return "", fmt.Errorf("关闭临时文件失败: %v", err)
+	}
+	committed = true
+	return tmpPath, nil
+}
+
+// ConvertToCBZ 将文件夹、zip、CBR、CB7、PDF或EPUB转换为整理过的CBZ
+// CBR、CB7和PDF需要安装对应的外部解包工具；源文件已在书库中且选择替换时，书库记录改为指向新文件
+func (a *App) ConvertToCBZ(sourcePath string, options ConvertOptions) (map[string]interface{}, error) {
+	sourcePath = filepath.Clean(sourcePath)
+	format, err := convertSourceFormat(sourcePath)
+	if err != nil {
+		return nil, err
+	}
+
+	outputPath := options.OutputPath
+	if outputPath == "" {
+		outputPath = strings.TrimSuffix(sourcePath, filepath.Ext(sourcePath)) + ".cbz"
+		if format == "folder" {
+			outputPath = sourcePath + ".cbz"
+		}
+	}
+	outputPath = filepath.Clean(outputPath)
+	// 输出到源文件本身（如整理已有的CBZ）会覆盖源文件，必须明确选择替换
+	inPlace := outputPath == sourcePath
+	if inPlace && !options.ReplaceSource {
+		return nil, fmt.Errorf("输出路径与源文件相同，需要选择替换源文件: %s", outputPath)
+	}
+	if _, err := os.Stat(outputPath); err == nil && !inPlace {
+		return nil, fmt.Errorf("目标文件已存在: %s", outputPath)
+	}
+
+	var comicID int64
+	title := strings.TrimSuffix(filepath.Base(sourcePath), filepath.Ext(sourcePath))
+	if format == "folder" {
+		title = filepath.Base(sourcePath)
+	}
+	if a.db != nil {
+		err := a.db.QueryRow(`SELECT id, title FROM comics WHERE file_path = ?`, sourcePath).Scan(&comicID, &title)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, fmt.Errorf("查询漫画信息失败: %v", err)
+		}
+	}
+
+	// 书库中的漫画转换期间不允许其他任务改写
+	if comicID > 0 {
+		unlock, err := a.lockComic(comicID)
+		if err != nil {
+			return nil, err
+		}
+		defer unlock()
+	}
+
+	pages, skipped, sourceInfo, cleanup, err := a.collectConvertPages(sourcePath, format)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+	if len(pages) == 0 {
+		return nil, fmt.Errorf("没有找到可转换的图片: %s", sourcePath)
+	}
+	// 被忽略规则或招募页过滤排除的图片不会写入CBZ，替换源文件会永久删除它们
+	if options.ReplaceSource && len(skipped) > 0 {
+		return nil, fmt.Errorf("有 %d 张图片被忽略规则排除，替换源文件会丢失这些图片: %s", len(skipped), strings.Join(skipped, ", "))
+	}
+
+	// 不写入新元数据时原样复制源压缩包中的ComicInfo.xml，不经过解析，避免丢失未建模的元素
+	var comicInfo *ComicInfo
+	var sourceComicInfo *zip.File
+	var sourceReader *zip.ReadCloser
+	closeSource := func() {
+		if sourceReader != nil {
+			sourceReader.Close()
+			sourceReader = nil
+		}
+	}
+	defer closeSource()
+	if options.EmbedComicInfo {
+		comicInfo = a.comicInfoForConversion(sourcePath, title, sourceInfo, len(pages))
+	} else if format == "zip" {
+		sourceReader, err = zip.OpenReader(sourcePath)
+		if err != nil {
+			return nil, fmt.Errorf("打开zip文件失败: %v", err)
+		}
+		for _, file := range sourceReader.File {
+			if isComicInfoEntry(file.Name) {
+				sourceComicInfo = file
+				break
+			}
+		}
+	}
+
+	tmpPath, err := writeCBZ(outputPath, pages, comicInfo, sourceComicInfo)
+	// Windows下必须先关闭源文件才能原地替换
+	closeSource()
+	if err != nil {
+		return nil, err
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			os.Remove(tmpPath)
+		}
+	}()
+
+	// 校验通过后才改名为输出文件，失败时丢弃临时文件，源文件保持不变
+	if status, problems := a.verifyPath(tmpPath, "zip"); status != integrityOK {
+		return nil, fmt.Errorf("转换结果校验失败: %s", problems[0].Reason)
+	}
+	if err := os.Rename(tmpPath, outputPath); err != nil {
+		return nil, fmt.Errorf("保存转换结果失败: %v", err)
+	}
+	committed = true
+
+	fileSize := int64(0)
+	if info, err := os.Stat(outputPath); err == nil {
+		fileSize = info.Size()
+	}
+	result := map[string]interface{}{
+		"sourcePath":   sourcePath,
+		"outputPath":   outputPath,
+		"pageCount":    len(pages),
+		"fileSize":     fileSize,
+		"comicInfo":    comicInfo != nil || sourceComicInfo != nil,
+		"replaced":     false,
+		"comicId":      comicID,
+		"skippedPages": skipped,
+	}
+
+	if !options.ReplaceSource {
+		fmt.Printf("已转换为CBZ: %s -> %s\n", sourcePath, outputPath)
+		return result, nil
+	}
+
+	if !inPlace {
+		if format == "folder" {
+			err = os.RemoveAll(sourcePath)
+		} else {
+			err = os.Remove(sourcePath)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("删除源文件失败: %v", err)
+		}
+	}
+	result["replaced"] = true
+
+	if comicID > 0 {
+		if err := a.replaceComicSource(comicID, outputPath, fileSize); err != nil {
+			return nil, err
+		}
+	}
+
+	fmt.Printf("已转换为CBZ并替换源文件: %s -> %s\n", sourcePath, outputPath)
+	return result, nil
+}
+
+// replaceComicSource 让书库中的漫画记录指向转换后的CBZ，并重建页面索引和封面
+func (a *App) replaceComicSource(comicID int64, outputPath string, fileSize int64) error {
+	// 转换后条目会重新命名，页面顺序不变，按原来的位置重新记录指定的封面页
+	coverIndex, err := a.coverPageIndex(comicID)
+	if err != nil {
+		return err
+	}
+
+	_, err = a.db.Exec(`UPDATE comics SET file_path = ?, file_type = 'zip', file_size = ?, hash_version = '', updated_at = ? WHERE id = ?`,
+		outputPath, fileSize, time.Now(), comicID)
+	if err != nil {
+		return fmt.Errorf("更新漫画信息失败: %v", err)
+	}
+
+	if err := a.indexComicPages(comicID); err != nil {
+		return err
+	}
+	if coverIndex >= 0 {
+		pages, err := a.loadComicPages(comicID)
+		if err != nil {
+			return err
+		}
+		if coverIndex < len(pages) {
+			_, err = a.db.Exec(`UPDATE comics SET cover_page_name = ? WHERE id = ?`, pages[coverIndex].Name, comicID)
+			if err != nil {
+				return fmt.Errorf("更新封面设置失败: %v", err)
+			}
+		}
+	}
+	return a.refreshCover(comicID)
+}
+
+// ConvertComicToCBZ 将书库中的漫画转换为CBZ
+func (a *App) ConvertComicToCBZ(comicID int64, options ConvertOptions) (map[string]interface{}, error) {
+	filePath, _, err := a.getComicPath(comicID)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.ConvertToCBZ(filePath, options)
+}
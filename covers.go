@@ -0,0 +1,374 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	"image"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+const (
+	coverSourceAuto   = "auto"   // 自动选择，跳过空白页
+	coverSourcePage   = "page"   // 用户指定的页面
+	coverSourceCustom = "custom" // 用户提供的外部图片
+)
+
+const (
+	// coverScanPages 自动选择封面时最多检查的页数
+	coverScanPages = 5
+	// blankPageRatio 与平均亮度差异明显的像素低于该比例时视为空白页
+	blankPageRatio = 0.01
+	// blankSampleGrid 检测空白页时的采样网格大小
+	blankSampleGrid = 64
+	// thumbnailWidth、thumbnailHeight 封面缩略图的最大尺寸
+	thumbnailWidth  = 300
+	thumbnailHeight = 450
+	// thumbnailQuality 缩略图的JPEG质量
+	thumbnailQuality = 85
+	// defaultThumbnailCacheBytes 缩略图缓存的大小上限
+	defaultThumbnailCacheBytes int64 = 512 << 20
+)
+
+// createCoverColumns 为漫画表添加封面设置和缩略图字段
+func (a *App) createCoverColumns() error {
+	coverColumns := [][2]string{
+		{"cover_source", "TEXT DEFAULT 'auto'"},
+		{"cover_page", "INTEGER DEFAULT 0"},
+		{"cover_page_name", "TEXT DEFAULT ''"},
+		{"cover_image", "TEXT DEFAULT ''"},
+		{"thumbnail", "TEXT DEFAULT ''"},
+	}
+	for _, column := range coverColumns {
+		if err := a.ensureColumn("comics", column[0], column[1]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// isNearBlankImage 判断图片是否接近空白：按网格采样，几乎所有像素都接近平均亮度
+func isNearBlankImage(img image.Image) bool {
+	bounds := img.Bounds()
+	if bounds.Dx() < 1 || bounds.Dy() < 1 {
+		return true
+	}
+
+	samples := make([]uint8, 0, blankSampleGrid*blankSampleGrid)
+	total := 0
+	for gy := 0; gy < blankSampleGrid; gy++ {
+		y := bounds.Min.Y + gy*bounds.Dy()/blankSampleGrid
+		for gx := 0; gx < blankSampleGrid; gx++ {
+			x := bounds.Min.X + gx*bounds.Dx()/blankSampleGrid
+			value := luminance(img, x, y)
+			samples = append(samples, value)
+			total += int(value)
+		}
+	}
+
+	mean := uint8(total / len(samples))
+	differing := 0
+	for _, value := range samples {
+		if !nearColor(value, mean) {
+			differing++
+		}
+	}
+
+	return float64(differing) < float64(len(samples))*blankPageRatio
+}
+
+// decodeCoverImage 解码页面或外部图片
+func (a *App) decodeCoverImage(path string) (image.Image, error) {
+	rc, err := a.openPage(comicPage{Path: path})
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	var img image.Image
+	err = a.imagePool.Do(func() error {
+		var err error
+		img, _, err = image.Decode(rc)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("解码封面图片失败: %v", err)
+	}
+
+	return img, nil
+}
+
+// pickAutoCover 自动选择封面：从第一页开始跳过接近空白的页面，全部空白时仍用第一页
+func (a *App) pickAutoCover(pages []comicPage) string {
+	for i := 0; i < len(pages) && i < coverScanPages; i++ {
+		img, err := a.decodeCoverImage(pages[i].Path)
+		if err != nil || !isNearBlankImage(img) {
+			return pages[i].Path
+		}
+		fmt.Printf("跳过空白页作为封面: %s\n", pages[i].Path)
+	}
+
+	return pages[0].Path
+}
+
+// generateThumbnail 生成封面缩略图并写入缩略图缓存，返回缩略图文件路径
+func (a *App) generateThumbnail(coverPath string) (string, error) {
+	if a.thumbnailCache == nil {
+		return "", nil
+	}
+
+	key := pageCacheKey(coverPath, sourceVersion(coverPath), "thumbnail")
+	if path, ok := a.thumbnailCache.cachedFile(key, ".jpg"); ok {
+		return path, nil
+	}
+
+	img, err := a.decodeCoverImage(coverPath)
+	if err != nil {
+		return "", err
+	}
+
+	var data []byte
+	err = a.imagePool.Do(func() error {
+		var err error
+		data, _, err = encodePageImage(resizeToFit(img, thumbnailWidth, thumbnailHeight), "jpeg", thumbnailQuality)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return a.thumbnailCache.storeFile(key, ".jpg", bytes.NewReader(data))
+}
+
+// findCoverPage 按页面名查找指定的封面页，重新打包后扩展名可能改变，找不到时再忽略扩展名匹配
+func findCoverPage(pages []comicPage, name string) int {
+	for i, page := range pages {
+		if page.Name == name {
+			return i
+		}
+	}
+
+	stem := strings.TrimSuffix(name, path.Ext(name))
+	for i, page := range pages {
+		if strings.TrimSuffix(page.Name, path.Ext(page.Name)) == stem {
+			return i
+		}
+	}
+	return -1
+}
+
+// coverPageIndex 获取用户指定的封面页在当前页面索引中的位置，未指定或已找不到时返回-1
+func (a *App) coverPageIndex(comicID int64) (int, error) {
+	var source, coverPageName string
+	var coverPage int
+	err := a.db.QueryRow(`SELECT COALESCE(cover_source, 'auto'), COALESCE(cover_page, 0), COALESCE(cover_page_name, '') FROM comics WHERE id = ?`,
+		comicID).Scan(&source, &coverPage, &coverPageName)
+	if err == sql.ErrNoRows {
+		return -1, fmt.Errorf("漫画不存在: %d", comicID)
+	}
+	if err != nil {
+		return -1, fmt.Errorf("查询封面设置失败: %v", err)
+	}
+	if source != coverSourcePage {
+		return -1, nil
+	}
+
+	pages, err := a.loadComicPages(comicID)
+	if err != nil {
+		return -1, err
+	}
+	// 旧版本只记录了页码
+	if coverPageName == "" {
+		if coverPage >= 0 && coverPage < len(pages) {
+			return coverPage, nil
+		}
+		return -1, nil
+	}
+	return findCoverPage(pages, coverPageName), nil
+}
+
+// refreshCover 根据封面设置确定封面图片，重新生成缩略图并更新漫画信息
+func (a *App) refreshCover(comicID int64) error {
+	if a.db == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+
+	var source, coverImage string
+	err := a.db.QueryRow(`SELECT COALESCE(cover_source, 'auto'), COALESCE(cover_image, '') FROM comics WHERE id = ?`,
+		comicID).Scan(&source, &coverImage)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("漫画不存在: %d", comicID)
+	}
+	if err != nil {
+		return fmt.Errorf("查询封面设置失败: %v", err)
+	}
+
+	var coverPath string
+	switch source {
+	case coverSourceCustom:
+		if _, err := os.Stat(coverImage); err == nil {
+			coverPath = coverImage
+		} else {
+			fmt.Printf("自定义封面不存在，改为自动选择: %s\n", coverImage)
+		}
+	case coverSourcePage:
+		index, err := a.coverPageIndex(comicID)
+		if err != nil {
+			return err
+		}
+		pages, err := a.loadComicPages(comicID)
+		if err != nil {
+			return err
+		}
+		if index >= 0 {
+			coverPath = pages[index].Path
+		} else {
+			fmt.Printf("指定的封面页已不存在，改为自动选择: %d\n", comicID)
+		}
+	}
+
+	if coverPath == "" {
+		pages, err := a.loadComicPages(comicID)
+		if err != nil {
+			return err
+		}
+		if len(pages) == 0 {
+			return nil
+		}
+		coverPath = a.pickAutoCover(pages)
+	}
+
+	thumbnail, err := a.generateThumbnail(coverPath)
+	if err != nil {
+		fmt.Printf("生成缩略图失败 %s: %v\n", coverPath, err)
+	}
+
+	_, err = a.db.Exec(`UPDATE comics SET first_image = ?, thumbnail = ? WHERE id = ?`, coverPath, thumbnail, comicID)
+	if err != nil {
+		return fmt.Errorf("更新封面失败: %v", err)
+	}
+
+	return nil
+}
+
+// SetComicCoverPage 指定漫画的某一页作为封面
+func (a *App) SetComicCoverPage(comicID int64, pageIndex int) error {
+	pages, err := a.loadComicPages(comicID)
+	if err != nil {
+		return err
+	}
+	if pageIndex < 0 || pageIndex >= len(pages) {
+		return fmt.Errorf("页码超出范围: %d", pageIndex)
+	}
+
+	// 保存页面名而不是页码，重新索引后页码可能指向别的页面
+	_, err = a.db.Exec(`UPDATE comics SET cover_source = ?, cover_page_name = ?, updated_at = ? WHERE id = ?`,
+		coverSourcePage, pages[pageIndex].Name, time.Now(), comicID)
+	if err != nil {
+		return fmt.Errorf("保存封面设置失败: %v", err)
+	}
+
+	return a.refreshCover(comicID)
+}
+
+// SetComicCoverImage 使用外部图片作为漫画封面
+func (a *App) SetComicCoverImage(comicID int64, imagePath string) error {
+	if a.db == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+
+	if _, err := a.decodeCoverImage(imagePath); err != nil {
+		return err
+	}
+
+	_, err := a.db.Exec(`UPDATE comics SET cover_source = ?, cover_image = ?, updated_at = ? WHERE id = ?`,
+		coverSourceCustom, imagePath, time.Now(), comicID)
+	if err != nil {
+		return fmt.Errorf("保存封面设置失败: %v", err)
+	}
+
+	return a.refreshCover(comicID)
+}
+
+// ResetComicCover 恢复自动选择封面
+func (a *App) ResetComicCover(comicID int64) error {
+	if a.db == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+
+	_, err := a.db.Exec(`UPDATE comics SET cover_source = ?, cover_page = 0, cover_page_name = '', cover_image = '', updated_at = ? WHERE id = ?`,
+		coverSourceAuto, time.Now(), comicID)
+	if err != nil {
+		return fmt.Errorf("保存封面设置失败: %v", err)
+	}
+
+	return a.refreshCover(comicID)
+}
+
+// GetComicCover 获取漫画的封面设置
+func (a *App) GetComicCover(comicID int64) (map[string]interface{}, error) {
+	if a.db == nil {
+		return nil, fmt.Errorf("数据库未初始化")
+	}
+
+	var source, coverPageName, coverImage, firstImage, thumbnail string
+	err := a.db.QueryRow(`SELECT COALESCE(cover_source, 'auto'), COALESCE(cover_page_name, ''), COALESCE(cover_image, ''),
+		COALESCE(first_image, ''), COALESCE(thumbnail, '') FROM comics WHERE id = ?`, comicID).Scan(&source, &coverPageName, &coverImage, &firstImage, &thumbnail)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("漫画不存在: %d", comicID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询封面设置失败: %v", err)
+	}
+
+	// 页码在读取时按页面名重新定位
+	coverPage, err := a.coverPageIndex(comicID)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"comicId":    comicID,
+		"source":     source,
+		"pageIndex":  coverPage,
+		"pageName":   coverPageName,
+		"coverImage": coverImage,
+		"firstImage": firstImage,
+		"thumbnail":  thumbnail,
+	}, nil
+}
+
+// RegenerateThumbnails 重新确定所有漫画的封面并生成缩略图，返回处理成功的数量
+func (a *App) RegenerateThumbnails() (int, error) {
+	if a.db == nil {
+		return 0, fmt.Errorf("数据库未初始化")
+	}
+
+	rows, err := a.db.Query(`SELECT id FROM comics`)
+	if err != nil {
+		return 0, fmt.Errorf("查询漫画失败: %v", err)
+	}
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	rows.Close()
+
+	count := 0
+	for _, id := range ids {
+		if err := a.refreshCover(id); err != nil {
+			fmt.Printf("重新生成封面失败 %d: %v\n", id, err)
+			continue
+		}
+		count++
+	}
+
+	return count, nil
+}
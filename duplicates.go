@@ -0,0 +1,438 @@
+package main
+
+import (
+	"context"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+const (
+	duplicateByContent       = "content"      // 文件内容完全相同
+	duplicateByPages         = "pages"        // 页数相同且每页内容相同，压缩包本身不同
+	duplicateByRecompression = "recompressed" // 每页画面相同，只是重新压缩过
+	duplicateByCover         = "cover"        // 封面感知哈希相近
+)
+
+// coverHashMaxDistance 封面哈希相差不超过该位数时视为同一本
+const coverHashMaxDistance = 6
+
+// comicHashRevision 哈希计算方式的版本，修改计算方式后递增，已保存的哈希会重新计算
+const comicHashRevision = "3"
+
+// createDuplicateColumns 为漫画表和图片表添加查重用的哈希字段
+func (a *App) createDuplicateColumns() error {
+	comicColumns := [][2]string{
+		{"content_hash", "TEXT DEFAULT ''"},
+		{"page_signature", "TEXT DEFAULT ''"},
+		{"cover_hash", "TEXT DEFAULT ''"},
+		{"hash_version", "TEXT DEFAULT ''"},
+	}
+	for _, column := range comicColumns {
+		if err := a.ensureColumn("comics", column[0], column[1]); err != nil {
+			return err
+		}
+	}
+
+	if err := a.ensureColumn("images", "page_hash", "TEXT DEFAULT ''"); err != nil {
+		return err
+	}
+	return a.ensureColumn("images", "phash", "TEXT DEFAULT ''")
+}
+
+// sha1Hex 计算数据流的SHA-1
+func sha1Hex(r io.Reader) (string, error) {
+	h := sha1.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// updateComicHashes 计算漫画的内容哈希、逐页哈希和封面感知哈希，文件未变化时跳过
+func (a *App) updateComicHashes(comicID int64) error {
+	filePath, fileType, err := a.getComicPath(comicID)
+	if err != nil {
+		return err
+	}
+
+	version := sourceVersion(filePath)
+	if version == "" {
+		return fmt.Errorf("漫画文件不存在: %s", filePath)
+	}
+	version = comicHashRevision + "-" + version
+
+	var storedVersion, firstImage string
+	err = a.db.QueryRow(`SELECT COALESCE(hash_version, ''), COALESCE(first_image, '') FROM comics WHERE id = ?`, comicID).Scan(&storedVersion, &firstImage)
+	if err != nil {
+		return fmt.Errorf("查询漫画信息失败: %v", err)
+	}
+	if storedVersion == version {
+		return nil
+	}
+	// 感知哈希的算法改变后，索引中保存的页面哈希也要重新计算
+	staleRevision := !strings.HasPrefix(storedVersion, comicHashRevision+"-")
+
+	pages, err := a.loadComicPages(comicID)
+	if err != nil {
+		return err
+	}
+
+	// 逐页哈希，文件夹的内容哈希由页面名和页面哈希组成
+	content := sha256.New()
+	pageHashes := make([]string, 0, len(pages))
+	for _, page := range pages {
+		rc, err := a.openPage(page)
+		if err != nil {
+			return err
+		}
+		pageHash, err := sha1Hex(rc)
+		rc.Close()
+		if err != nil {
+			return fmt.Errorf("读取页面失败 %s: %v", page.Name, err)
+		}
+
+		pageHashes = append(pageHashes, pageHash)
+		fmt.Fprintf(content, "%s\x00%s\n", page.Name, pageHash)
+		// 建立索引时不解码页面，感知哈希在这里补上，算法改变后也重新计算
+		if page.PHash == "" || staleRevision {
+			page.PHash, _ = a.pagePerceptualHash(page.Path)
+		}
+		if _, err := a.db.Exec(`UPDATE images SET page_hash = ?, phash = ? WHERE comic_id = ? AND page_index = ?`, pageHash, page.PHash, comicID, page.Index); err != nil {
+			return fmt.Errorf("保存页面哈希失败: %v", err)
+		}
+	}
+
+	contentHash := hex.EncodeToString(content.Sum(nil))
+	if fileType == "zip" {
+		file, err := os.Open(filePath)
+		if err != nil {
+			return fmt.Errorf("打开zip文件失败: %v", err)
+		}
+		full := sha256.New()
+		_, err = io.Copy(full, file)
+		file.Close()
+		if err != nil {
+			return fmt.Errorf("读取zip文件失败: %v", err)
+		}
+		contentHash = hex.EncodeToString(full.Sum(nil))
+	}
+
+	signature := ""
+	if len(pageHashes) > 0 {
+		sum := sha256.Sum256([]byte(strings.Join(pageHashes, ",")))
+		signature = hex.EncodeToString(sum[:])
+	}
+
+	// 空白或纯色封面的感知哈希几乎相同，不参与封面分组
+	coverHash := ""
+	if firstImage != "" {
+		if img, err := a.decodeCoverImage(firstImage); err == nil && !isNearBlankImage(img) {
+			coverHash = formatHash(dHash(img))
+		}
+	}
+
+	_, err = a.db.Exec(`UPDATE comics SET content_hash = ?, page_signature = ?, cover_hash = ?, hash_version = ? WHERE id = ?`,
+		contentHash, signature, coverHash, version, comicID)
+	if err != nil {
+		return fmt.Errorf("保存漫画哈希失败: %v", err)
+	}
+
+	return nil
+}
+
+// comicHashRow 查重时使用的哈希信息
+type comicHashRow struct {
+	id        int64
+	content   string
+	signature string
+	cover     string
+}
+
+// groupByKey 按相同的键分组，只保留两个以上的组
+func groupByKey(rows []comicHashRow, key func(comicHashRow) string) [][]int64 {
+	groups := make(map[string][]int64)
+	var order []string
+	for _, row := range rows {
+		k := key(row)
+		if k == "" {
+			continue
+		}
+		if _, ok := groups[k]; !ok {
+			order = append(order, k)
+		}
+		groups[k] = append(groups[k], row.id)
+	}
+
+	var result [][]int64
+	for _, k := range order {
+		if len(groups[k]) > 1 {
+			result = append(result, groups[k])
+		}
+	}
+	return result
+}
+
+// groupPairs 用并查集把成对相似的漫画合并为组，组内按ID排序
+func groupPairs(pairs [][2]int64) [][]int64 {
+	parent := make(map[int64]int64)
+	var find func(int64) int64
+	find = func(id int64) int64 {
+		if p, ok := parent[id]; ok && p != id {
+			parent[id] = find(p)
+			return parent[id]
+		}
+		parent[id] = id
+		return id
+	}
+	for _, pair := range pairs {
+		x, y := find(pair[0]), find(pair[1])
+		if x != y {
+			parent[y] = x
+		}
+	}
+
+	members := make(map[int64][]int64)
+	var roots []int64
+	for id := range parent {
+		root := find(id)
+		if _, ok := members[root]; !ok {
+			roots = append(roots, root)
+		}
+		members[root] = append(members[root], id)
+	}
+	sort.Slice(roots, func(i, j int) bool { return roots[i] < roots[j] })
+
+	var result [][]int64
+	for _, root := range roots {
+		group := members[root]
+		sort.Slice(group, func(i, j int) bool { return group[i] < group[j] })
+		result = append(result, group)
+	}
+	return result
+}
+
+// groupByCoverHash 将封面哈希相近的漫画合并为一组
+func groupByCoverHash(rows []comicHashRow) [][]int64 {
+	var hashed []comicHashRow
+	var hashes []uint64
+	for _, row := range rows {
+		if hash, ok := parseHash(row.cover); ok {
+			hashed = append(hashed, row)
+			hashes = append(hashes, hash)
+		}
+	}
+
+	var pairs [][2]int64
+	for i := range hashed {
+		for j := i + 1; j < len(hashed); j++ {
+			if hashDistance(hashes[i], hashes[j]) <= coverHashMaxDistance {
+				pairs = append(pairs, [2]int64{hashed[i].id, hashed[j].id})
+			}
+		}
+	}
+	return groupPairs(pairs)
+}
+
+// groupByRecompression 页面感知哈希逐页一致、但文件和页面内容都不同的漫画视为重新压缩的版本
+func groupByRecompression(rows []comicHashRow, diffs []pageDifference) [][]int64 {
+	byID := make(map[int64]comicHashRow, len(rows))
+	for _, row := range rows {
+		byID[row.id] = row
+	}
+
+	var pairs [][2]int64
+	for _, diff := range diffs {
+		if len(diff.onlyFirst) > 0 || len(diff.onlySecond) > 0 {
+			continue
+		}
+		first, second := byID[diff.first], byID[diff.second]
+		if first.content == second.content || first.signature == second.signature {
+			continue
+		}
+		pairs = append(pairs, [2]int64{diff.first, diff.second})
+	}
+	return groupPairs(pairs)
+}
+
+// groupKey 用排序后的ID标识一组漫画，用于去掉重复的分组
+func groupKey(ids []int64) string {
+	sorted := append([]int64{}, ids...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return fmt.Sprint(sorted)
+}
+
+// allComicIDs 查询书库中所有漫画的ID
+func (a *App) allComicIDs() ([]int64, error) {
+	rows, err := a.db.Query(`SELECT id FROM comics ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("查询漫画失败: %v", err)
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// updateAllComicHashes 在后台任务中更新漫画的哈希，每本计入一次进度，单本失败时只记录日志
+func (a *App) updateAllComicHashes(ctx context.Context, job *backgroundJob, ids []int64) error {
+	for _, id := range ids {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err := a.updateComicHashes(id); err != nil {
+			fmt.Printf("计算漫画哈希失败 %d: %v\n", id, err)
+		}
+		job.advance(fmt.Sprintf("已计算漫画 %d 的哈希", id), nil)
+	}
+	return nil
+}
+
+// comicsByIDs 按添加时间顺序查询一组漫画
+func (a *App) comicsByIDs(ids []int64) ([]map[string]interface{}, error) {
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	return a.queryComics(`SELECT `+comicSelectColumns+` FROM comics c WHERE c.id IN (`+placeholders+`) ORDER BY c.created_at, c.id`, args...)
+}
+
+// findDuplicateGroups 按已保存的哈希分组，依次按文件内容、逐页内容、逐页画面和封面相似度分组
+// 同一组漫画只在最先匹配的分类中出现
+func (a *App) findDuplicateGroups() ([]map[string]interface{}, error) {
+	rows, err := a.db.Query(`SELECT id, COALESCE(content_hash, ''), COALESCE(page_signature, ''), COALESCE(cover_hash, '') FROM comics ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("查询漫画哈希失败: %v", err)
+	}
+	var hashRows []comicHashRow
+	for rows.Next() {
+		var row comicHashRow
+		if err := rows.Scan(&row.id, &row.content, &row.signature, &row.cover); err == nil {
+			hashRows = append(hashRows, row)
+		}
+	}
+	rows.Close()
+
+	diffs, err := a.comparePageSequences(0)
+	if err != nil {
+		return nil, err
+	}
+
+	kinds := []struct {
+		kind   string
+		groups [][]int64
+	}{
+		{duplicateByContent, groupByKey(hashRows, func(r comicHashRow) string { return r.content })},
+		{duplicateByPages, groupByKey(hashRows, func(r comicHashRow) string { return r.signature })},
+		{duplicateByRecompression, groupByRecompression(hashRows, diffs)},
+		{duplicateByCover, groupByCoverHash(hashRows)},
+	}
+
+	seen := make(map[string]bool)
+	var result []map[string]interface{}
+	for _, kind := range kinds {
+		for _, group := range kind.groups {
+			key := groupKey(group)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			comics, err := a.comicsByIDs(group)
+			if err != nil {
+				return nil, err
+			}
+
+			result = append(result, map[string]interface{}{
+				"kind":   kind.kind,
+				"comics": comics,
+			})
+		}
+	}
+
+	return result, nil
+}
+
+// StartDuplicateScan 在后台计算全部漫画的哈希并查找重复，返回任务ID
+// 任务结果为重复分组，每组包含kind和comics
+func (a *App) StartDuplicateScan() (string, error) {
+	if a.db == nil {
+		return "", fmt.Errorf("数据库未初始化")
+	}
+
+	ids, err := a.allComicIDs()
+	if err != nil {
+		return "", err
+	}
+
+	// 最后一步为分组
+	return a.startJob("duplicates", len(ids)+1, func(ctx context.Context, job *backgroundJob) error {
+		if err := a.updateAllComicHashes(ctx, job, ids); err != nil {
+			return err
+		}
+
+		groups, err := a.findDuplicateGroups()
+		if err != nil {
+			return err
+		}
+		for _, group := range groups {
+			job.addResult(group)
+		}
+		job.advance(fmt.Sprintf("找到 %d 组重复漫画", len(groups)), nil)
+		return nil
+	}), nil
+}
+
+// ResolveDuplicates 处理一组重复漫画：保留keepID，从库中移除其余漫画，deleteFiles为true时同时删除磁盘上的文件
+func (a *App) ResolveDuplicates(keepID int64, removeIDs []int64, deleteFiles bool) error {
+	if a.db == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+
+	if _, _, err := a.getComicPath(keepID); err != nil {
+		return err
+	}
+
+	for _, id := range removeIDs {
+		if id == keepID {
+			return fmt.Errorf("不能删除要保留的漫画: %d", keepID)
+		}
+	}
+
+	for _, id := range removeIDs {
+		filePath, fileType, err := a.getComicPath(id)
+		if err != nil {
+			return err
+		}
+
+		if deleteFiles {
+			if fileType == "folder" {
+				err = os.RemoveAll(filePath)
+			} else {
+				err = os.Remove(filePath)
+			}
+			if err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("删除文件失败 %s: %v", filePath, err)
+			}
+			fmt.Printf("已删除重复文件: %s\n", filePath)
+		}
+
+		if err := a.DeleteComicFromDatabase(id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
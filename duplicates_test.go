@@ -0,0 +1,95 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestGroupByKey 相同的键分为一组，空键和只有一本的组不返回
+func TestGroupByKey(t *testing.T) {
+	rows := []comicHashRow{
+		{id: 1, content: "a"},
+		{id: 2, content: "b"},
+		{id: 3, content: "a"},
+		{id: 4, content: ""},
+		{id: 5, content: ""},
+		{id: 6, content: "b"},
+		{id: 7, content: "c"},
+	}
+
+	got := groupByKey(rows, func(r comicHashRow) string { return r.content })
+	want := [][]int64{{1, 3}, {2, 6}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("groupByKey = %v, want %v", got, want)
+	}
+}
+
+// TestGroupByCoverHash 封面哈希相差不超过coverHashMaxDistance位的漫画合并为一组，可以传递
+func TestGroupByCoverHash(t *testing.T) {
+	tests := []struct {
+		name string
+		rows []comicHashRow
+		want [][]int64
+	}{
+		{
+			"identical covers",
+			[]comicHashRow{{id: 1, cover: "00000000000000ff"}, {id: 2, cover: "00000000000000ff"}},
+			[][]int64{{1, 2}},
+		},
+		{
+			"within distance",
+			[]comicHashRow{{id: 1, cover: "0000000000000000"}, {id: 2, cover: "000000000000003f"}},
+			[][]int64{{1, 2}},
+		},
+		{
+			"beyond distance",
+			[]comicHashRow{{id: 1, cover: "0000000000000000"}, {id: 2, cover: "000000000000007f"}},
+			nil,
+		},
+		{
+			"transitive",
+			[]comicHashRow{{id: 3, cover: "0000000000000000"}, {id: 1, cover: "000000000000003f"}, {id: 2, cover: "0000000000000fff"}},
+			[][]int64{{1, 2, 3}},
+		},
+		{
+			"missing hashes ignored",
+			[]comicHashRow{{id: 1, cover: ""}, {id: 2, cover: ""}, {id: 3, cover: "zz"}},
+			nil,
+		},
+	}
+
+	for _, tt := range tests {
+		if got := groupByCoverHash(tt.rows); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: groupByCoverHash = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// TestGroupByRecompression 逐页画面一致但文件和页面内容都不同的漫画视为重新压缩的版本
+func TestGroupByRecompression(t *testing.T) {
+	rows := []comicHashRow{
+		{id: 1, content: "c1", signature: "s1"},
+		{id: 2, content: "c2", signature: "s2"},
+		{id: 3, content: "c3", signature: "s1"},
+		{id: 4, content: "c4", signature: "s4"},
+		{id: 5, content: "c5", signature: "s5"},
+	}
+
+	tests := []struct {
+		name  string
+		diffs []pageDifference
+		want  [][]int64
+	}{
+		{"recompressed", []pageDifference{{first: 1, second: 2}}, [][]int64{{1, 2}}},
+		{"same page content", []pageDifference{{first: 1, second: 3}}, nil},
+		{"missing pages", []pageDifference{{first: 1, second: 4, onlyFirst: []int{3}}}, nil},
+		{"extra pages", []pageDifference{{first: 2, second: 4, onlySecond: []int{0}}}, nil},
+		{"chained", []pageDifference{{first: 4, second: 5}, {first: 2, second: 4}}, [][]int64{{2, 4, 5}}},
+	}
+
+	for _, tt := range tests {
+		if got := groupByRecompression(rows, tt.diffs); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: groupByRecompression = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,667 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/xml"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	spreadKeep   = "keep"   // 跨页保持原样作为一页
+	spreadSplit  = "split"  // 跨页切成左右两页，按阅读方向排列
+	spreadRotate = "rotate" // 跨页旋转90度，适合竖屏阅读器
+)
+
+// EPUBExportOptions 导出EPUB的选项
+type EPUBExportOptions struct {
+	OutputDir        string `json:"outputDir"`        // 输出目录，为空时与源文件同目录
+	Device           string `json:"device"`           // 设备配置，决定分辨率和是否转灰度
+	Width            int    `json:"width"`            // 自定义分辨率，大于0时覆盖设备配置
+	Height           int    `json:"height"`           // 自定义分辨率，大于0时覆盖设备配置
+	Grayscale        bool   `json:"grayscale"`        // 转为灰度，设备为墨水屏时自动开启
+	SpreadMode       string `json:"spreadMode"`       // keep、split、rotate，为空时保持原样
+	ReadingDirection string `json:"readingDirection"` // ltr、rtl，为空时使用漫画的阅读模式
+	Quality          int    `json:"quality"`          // 重新编码JPEG的质量，0使用默认值
+}
+
+// epubDeviceProfile 阅读器的屏幕分辨率和是否为黑白屏
+type epubDeviceProfile struct {
+	Name      string `json:"name"`
+	Width     int    `json:"width"`
+	Height    int    `json:"height"`
+	Grayscale bool   `json:"grayscale"`
+}
+
+// epubDeviceProfiles 常见阅读器的屏幕参数
+var epubDeviceProfiles = map[string]epubDeviceProfile{
+	"kobo-clara":        {Name: "Kobo Clara HD / Clara 2E", Width: 1072, Height: 1448, Grayscale: true},
+	"kobo-clara-colour": {Name: "Kobo Clara Colour", Width: 1072, Height: 1448},
+	"kobo-libra":        {Name: "Kobo Libra 2", Width: 1264, Height: 1680, Grayscale: true},
+	"kobo-libra-colour": {Name: "Kobo Libra Colour", Width: 1264, Height: 1680},
+	"kobo-sage":         {Name: "Kobo Sage", Width: 1440, Height: 1920, Grayscale: true},
+	"kobo-elipsa":       {Name: "Kobo Elipsa 2E", Width: 1404, Height: 1872, Grayscale: true},
+	"kindle":            {Name: "Kindle (2022)", Width: 1072, Height: 1448, Grayscale: true},
+	"kindle-paperwhite": {Name: "Kindle Paperwhite 5", Width: 1236, Height: 1648, Grayscale: true},
+	"kindle-oasis":      {Name: "Kindle Oasis", Width: 1264, Height: 1680, Grayscale: true},
+	"kindle-scribe":     {Name: "Kindle Scribe", Width: 1860, Height: 2480, Grayscale: true},
+	"kindle-colorsoft":  {Name: "Kindle Colorsoft", Width: 1264, Height: 1680},
+}
+
+// epubMediaTypes 阅读器普遍支持、可以直接写入EPUB的图片格式
+var epubMediaTypes = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+}
+
+// epubImage 写入EPUB的一页图片
+type epubImage struct {
+	data      []byte
+	mediaType string
+	width     int
+	height    int
+}
+
+// epubPageFile 已写入EPUB的页面
+type epubPageFile struct {
+	id        string
+	image     string
+	xhtml     string
+	mediaType string
+	width     int
+	height    int
+}
+
+// xmlText 转义XML文本
+func xmlText(s string) string {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
+
+// epubIdentifier 由文件路径生成固定的UUID，重复导出时标识不变，阅读器能识别为同一本书
+func epubIdentifier(filePath string) string {
+	sum := sha1.Sum([]byte(filePath))
+	return fmt.Sprintf("%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
+
+// rotateClockwise 将图片顺时针旋转90度
+func rotateClockwise(img image.Image) image.Image {
+	bounds := img.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, bounds.Dy(), bounds.Dx()))
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			dst.Set(bounds.Max.Y-1-y, x-bounds.Min.X, img.At(x, y))
+		}
+	}
+	return dst
+}
+
+// toGrayscale 转为灰度图，墨水屏上体积更小且显示效果相同
+func toGrayscale(img image.Image) image.Image {
+	if gray, ok := img.(*image.Gray); ok {
+		return gray
+	}
+	bounds := img.Bounds()
+	gray := image.NewGray(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(gray, gray.Bounds(), img, bounds.Min, draw.Src)
+	return gray
+}
+
+// hasColor 判断图片是否有彩色像素，没有时转灰度不会改变画面
+func hasColor(img image.Image) bool {
+	switch img.(type) {
+	case *image.Gray, *image.Gray16:
+		return false
+	}
+	bounds := img.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, g, b, _ := img.At(x, y).RGBA()
+			if r != g || g != b {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// exceedsSize 判断图片是否超出限制，resizeToFit只在超出时缩小
+func exceedsSize(width, height, maxWidth, maxHeight int) bool {
+	return (maxWidth > 0 && width > maxWidth) || (maxHeight > 0 && height > maxHeight)
+}
+
+// epubPageImages 读取一页并按选项处理，跨页切分时返回两张图片
+// 只有需要缩小、转灰度会改变画面、切分或旋转跨页时才重新编码，其余受支持的格式直接使用原图数据
+func (a *App) epubPageImages(page comicPage, options EPUBExportOptions, rightToLeft bool) ([]epubImage, error) {
+	rc, err := a.openPage(page)
+	if err != nil {
+		return nil, err
+	}
+	original, err := io.ReadAll(rc)
+	rc.Close()
+	if err != nil {
+		return nil, fmt.Errorf("读取页面 %s 失败: %v", page.Name, err)
+	}
+
+	mediaType := sniffImageType(original)
+	spread := isSpreadPage(page) && options.SpreadMode != spreadKeep
+	width, height := page.Width, page.Height
+	if width <= 0 || height <= 0 {
+		if config, _, err := image.DecodeConfig(bytes.NewReader(original)); err == nil {
+			width, height = config.Width, config.Height
+		}
+	}
+	_, supported := epubMediaTypes[mediaType]
+	keepOriginal := supported && !spread && width > 0 && height > 0 && !exceedsSize(width, height, options.Width, options.Height)
+	if keepOriginal && !options.Grayscale {
+		return []epubImage{{data: original, mediaType: mediaType, width: width, height: height}}, nil
+	}
+
+	var images []epubImage
+	err = a.imagePool.Do(func() error {
+		img, _, err := image.Decode(bytes.NewReader(original))
+		if err != nil {
+			return fmt.Errorf("解码页面 %s 失败: %v", page.Name, err)
+		}
+		if keepOriginal && !hasColor(img) {
+			images = []epubImage{{data: original, mediaType: mediaType, width: width, height: height}}
+			return nil
+		}
+
+		parts := []image.Image{img}
+		if spread {
+			switch options.SpreadMode {
+			case spreadSplit:
+				left, right := splitImageHalf(img, pageHalfLeft), splitImageHalf(img, pageHalfRight)
+				parts = []image.Image{left, right}
+				if rightToLeft {
+					parts = []image.Image{right, left}
+				}
+			case spreadRotate:
+				parts = []image.Image{rotateClockwise(img)}
+			}
+		}
+
+		for _, part := range parts {
+			part = resizeToFit(part, options.Width, options.Height)
+			if options.Grayscale {
+				part = toGrayscale(part)
+			}
+			var buf bytes.Buffer
+			if err := jpeg.Encode(&buf, part, &jpeg.Options{Quality: options.Quality}); err != nil {
+				return fmt.Errorf("编码JPEG失败: %v", err)
+			}
+			bounds := part.Bounds()
+			images = append(images, epubImage{data: buf.Bytes(), mediaType: "image/jpeg", width: bounds.Dx(), height: bounds.Dy()})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return images, nil
+}
+
+// resolveEPUBOptions 合并设备配置并检查选项
+func resolveEPUBOptions(options EPUBExportOptions) (EPUBExportOptions, error) {
+	if options.Device != "" {
+		profile, ok := epubDeviceProfiles[options.Device]
+		if !ok {
+			return options, fmt.Errorf("未知的设备配置: %s", options.Device)
+		}
+		if options.Width <= 0 && options.Height <= 0 {
+			options.Width, options.Height = profile.Width, profile.Height
+		}
+		options.Grayscale = options.Grayscale || profile.Grayscale
+	}
+
+	switch options.SpreadMode {
+	case "":
+		options.SpreadMode = spreadKeep
+	case spreadKeep, spreadSplit, spreadRotate:
+	default:
+		return options, fmt.Errorf("无效的跨页处理方式: %s", options.SpreadMode)
+	}
+
+	switch options.ReadingDirection {
+	case "", readingModeLTR, readingModeRTL:
+	default:
+		return options, fmt.Errorf("无效的阅读方向: %s", options.ReadingDirection)
+	}
+
+	if options.Quality <= 0 || options.Quality > 100 {
+		options.Quality = defaultJPEGQuality
+	}
+	return options, nil
+}
+
+// writeEPUBEntry 写入一个EPUB条目
+func writeEPUBEntry(writer *zip.Writer, name string, method uint16, data []byte) error {
+	w, err := writer.CreateHeader(&zip.FileHeader{Name: name, Method: method, Modified: time.Now()})
+	if err != nil {
+		return fmt.Errorf("创建条目 %s 失败: %v", name, err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("写入条目 %s 失败: %v", name, err)
+	}
+	return nil
+}
+
+// epubPageXHTML 固定版式的单页，视口与图片尺寸相同
+func epubPageXHTML(title, imageHref string, width, height int) string {
+	return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops">
+<head>
+  <title>%s</title>
+  <meta name="viewport" content="width=%d, height=%d"/>
+  <style>html, body { margin: 0; padding: 0; width: %dpx; height: %dpx; } img { display: block; width: %dpx; height: %dpx; }</style>
+</head>
+<body>
+  <img src="%s" alt=""/>
+</body>
+</html>
+`, xmlText(title), width, height, width, height, width, height, xmlText(imageHref))
+}
+
+// epubMetadata 根据ComicInfo生成OPF的元数据部分
+func epubMetadata(identifier, title string, info *ComicInfo, rightToLeft bool, coverID string, width, height int) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "    <dc:identifier id=\"bookid\">urn:uuid:%s</dc:identifier>\n", identifier)
+	fmt.Fprintf(&b, "    <dc:title>%s</dc:title>\n", xmlText(title))
+
+	language := "und"
+	if info != nil && info.LanguageISO != "" {
+		language = info.LanguageISO
+	}
+	fmt.Fprintf(&b, "    <dc:language>%s</dc:language>\n", xmlText(language))
+
+	if info != nil {
+		creators := [][2]string{{info.Writer, "aut"}, {info.Penciller, "art"}, {info.Translator, "trl"}}
+		count := 0
+		for _, creator := range creators {
+			for _, name := range strings.Split(creator[0], ",") {
+				if name = strings.TrimSpace(name); name == "" {
+					continue
+				}
+				count++
+				fmt.Fprintf(&b, "    <dc:creator id=\"creator%d\">%s</dc:creator>\n", count, xmlText(name))
+				fmt.Fprintf(&b, "    <meta refines=\"#creator%d\" property=\"role\" scheme=\"marc:relators\">%s</meta>\n", count, creator[1])
+			}
+		}
+		if info.Publisher != "" {
+			fmt.Fprintf(&b, "    <dc:publisher>%s</dc:publisher>\n", xmlText(info.Publisher))
+		}
+		if info.Summary != "" {
+			fmt.Fprintf(&b, "    <dc:description>%s</dc:description>\n", xmlText(info.Summary))
+		}
+		for _, subject := range strings.Split(info.Genre+","+info.Tags, ",") {
+			if subject = strings.TrimSpace(subject); subject != "" {
+				fmt.Fprintf(&b, "    <dc:subject>%s</dc:subject>\n", xmlText(subject))
+			}
+		}
+		if info.Year > 0 {
+			date := strconv.Itoa(info.Year)
+			if info.Month > 0 {
+				date += fmt.Sprintf("-%02d", info.Month)
+				if info.Day > 0 {
+					date += fmt.Sprintf("-%02d", info.Day)
+				}
+			}
+			fmt.Fprintf(&b, "    <dc:date>%s</dc:date>\n", date)
+		}
+		if info.Series != "" {
+			fmt.Fprintf(&b, "    <meta property=\"belongs-to-collection\" id=\"series\">%s</meta>\n", xmlText(info.Series))
+			fmt.Fprintf(&b, "    <meta refines=\"#series\" property=\"collection-type\">series</meta>\n")
+			position := info.Number
+			if position == "" && info.Volume > 0 {
+				position = strconv.Itoa(info.Volume)
+			}
+			if position != "" {
+				fmt.Fprintf(&b, "    <meta refines=\"#series\" property=\"group-position\">%s</meta>\n", xmlText(position))
+			}
+		}
+	}
+
+	fmt.Fprintf(&b, "    <meta property=\"dcterms:modified\">%s</meta>\n", time.Now().UTC().Format("2006-01-02T15:04:05Z"))
+	b.WriteString("    <meta property=\"rendition:layout\">pre-paginated</meta>\n")
+	b.WriteString("    <meta property=\"rendition:orientation\">portrait</meta>\n")
+	b.WriteString("    <meta property=\"rendition:spread\">none</meta>\n")
+	// Kindle识别固定版式漫画用的EPUB2元数据
+	fmt.Fprintf(&b, "    <meta name=\"cover\" content=\"%s\"/>\n", coverID)
+	b.WriteString("    <meta name=\"fixed-layout\" content=\"true\"/>\n")
+	b.WriteString("    <meta name=\"book-type\" content=\"comic\"/>\n")
+	fmt.Fprintf(&b, "    <meta name=\"original-resolution\" content=\"%dx%d\"/>\n", width, height)
+	if rightToLeft {
+		b.WriteString("    <meta name=\"primary-writing-mode\" content=\"horizontal-rl\"/>\n")
+	}
+	return b.String()
+}
+
+// exportComicToEPUB 把一本漫画导出为固定版式EPUB，先写临时文件再改名
+func (a *App) exportComicToEPUB(ctx context.Context, comicID int64, options EPUBExportOptions) (map[string]interface{}, error) {
+	filePath, fileType, err := a.getComicPath(comicID)
+	if err != nil {
+		return nil, err
+	}
+	pages, err := a.loadComicPages(comicID)
+	if err != nil {
+		return nil, err
+	}
+	if len(pages) == 0 {
+		return nil, fmt.Errorf("漫画没有页面: %s", filePath)
+	}
+
+	var title, coverPath, coverSource, coverImage string
+	err = a.db.QueryRow(`SELECT title, COALESCE(first_image, ''), COALESCE(cover_source, 'auto'), COALESCE(cover_image, '') FROM comics WHERE id = ?`,
+		comicID).Scan(&title, &coverPath, &coverSource, &coverImage)
+	if err != nil {
+		return nil, fmt.Errorf("查询漫画信息失败: %v", err)
+	}
+	var info *ComicInfo
+	if fileType == "zip" {
+		if info, err = a.readComicInfoFromZip(filePath); err != nil {
+			fmt.Printf("读取ComicInfo.xml失败 %s: %v\n", filePath, err)
+		}
+	}
+	if info != nil && strings.TrimSpace(info.Title) != "" {
+		title = strings.TrimSpace(info.Title)
+	}
+
+	direction := options.ReadingDirection
+	if direction == "" {
+		mode, _, err := a.resolveReadingMode(comicID, pages)
+		if err != nil {
+			return nil, err
+		}
+		direction = readingModeLTR
+		if mode == readingModeRTL {
+			direction = readingModeRTL
+		}
+	}
+	rightToLeft := direction == readingModeRTL
+
+	outputDir := options.OutputDir
+	if outputDir == "" {
+		outputDir = filepath.Dir(filePath)
+	}
+	baseName := filepath.Base(filePath)
+	if fileType != "folder" {
+		baseName = strings.TrimSuffix(baseName, filepath.Ext(baseName))
+	}
+	outputPath := filepath.Join(outputDir, baseName+".epub")
+	if _, err := os.Stat(outputPath); err == nil {
+		return nil, fmt.Errorf("目标文件已存在: %s", outputPath)
+	}
+
+	tmpFile, err := os.CreateTemp(outputDir, "."+baseName+".*.tmp")
+	if err != nil {
+		return nil, fmt.Errorf("创建临时文件失败: %v", err)
+	}
+	tmpPath := tmpFile.Name()
+	committed := false
+	defer func() {
+		if !committed {
+			tmpFile.Close()
+			os.Remove(tmpPath)
+		}
+	}()
+
+	// mimetype必须是第一个条目且不压缩
+	writer := zip.NewWriter(tmpFile)
+	if err := writeEPUBEntry(writer, "mimetype", zip.Store, []byte("application/epub+zip")); err != nil {
+		return nil, err
+	}
+	container := `<?xml version="1.0" encoding="UTF-8"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles>
+    <rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/>
+  </rootfiles>
+</container>
+`
+	if err := writeEPUBEntry(writer, "META-INF/container.xml", zip.Deflate, []byte(container)); err != nil {
+		return nil, err
+	}
+
+	var files []epubPageFile
+	addImage := func(img epubImage) error {
+		number := len(files) + 1
+		file := epubPageFile{
+			id:        fmt.Sprintf("page%04d", number),
+			image:     fmt.Sprintf("images/%04d%s", number, epubMediaTypes[img.mediaType]),
+			xhtml:     fmt.Sprintf("pages/%04d.xhtml", number),
+			mediaType: img.mediaType,
+			width:     img.width,
+			height:    img.height,
+		}
+		if err := writeEPUBEntry(writer, "OEBPS/"+file.image, zip.Store, img.data); err != nil {
+			return err
+		}
+		page := epubPageXHTML(fmt.Sprintf("%s - %d", title, number), "../"+file.image, img.width, img.height)
+		if err := writeEPUBEntry(writer, "OEBPS/"+file.xhtml, zip.Deflate, []byte(page)); err != nil {
+			return err
+		}
+		files = append(files, file)
+		return nil
+	}
+
+	// 封面：自定义图片作为单独的第一页，否则标记封面所在的页面
+	// 自定义图片不存在时 refreshCover 已改用书中的某一页作为封面，不能再把该页单独加一次
+	coverIndex := 0
+	customCover := false
+	if coverSource == coverSourceCustom && coverImage != "" {
+		if _, err := os.Stat(coverImage); err != nil {
+			fmt.Printf("自定义封面不存在，使用书中的封面页: %s\n", coverImage)
+		} else {
+			coverOptions := options
+			coverOptions.SpreadMode = spreadKeep
+			images, err := a.epubPageImages(comicPage{Name: filepath.Base(coverImage), Path: coverImage}, coverOptions, rightToLeft)
+			if err != nil {
+				fmt.Printf("读取自定义封面失败 %s: %v\n", coverImage, err)
+			} else if err := addImage(images[0]); err != nil {
+				return nil, err
+			} else {
+				customCover = true
+			}
+		}
+	}
+
+	// 按章节记录每章第一页在EPUB中的位置，用于生成目录
+	type tocEntry struct {
+		label string
+		href  string
+	}
+	var toc []tocEntry
+	lastChapter := "\x00"
+	for _, page := range pages {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		images, err := a.epubPageImages(page, options, rightToLeft)
+		if err != nil {
+			return nil, err
+		}
+		if page.Path == coverPath && !customCover {
+			coverIndex = len(files)
+		}
+		if page.Chapter != lastChapter {
+			lastChapter = page.Chapter
+			label := path.Base(page.Chapter)
+			if page.Chapter == "" {
+				label = title
+			}
+			toc = append(toc, tocEntry{label: label, href: fmt.Sprintf("pages/%04d.xhtml", len(files)+1)})
+		}
+		for _, img := range images {
+			if err := addImage(img); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// 目录：EPUB3导航文档和供旧阅读器使用的NCX
+	var navItems, ncxPoints strings.Builder
+	for i, entry := range toc {
+		fmt.Fprintf(&navItems, "      <li><a href=\"%s\">%s</a></li>\n", entry.href, xmlText(entry.label))
+		fmt.Fprintf(&ncxPoints, "    <navPoint id=\"nav%d\" playOrder=\"%d\"><navLabel><text>%s</text></navLabel><content src=\"%s\"/></navPoint>\n",
+			i+1, i+1, xmlText(entry.label), entry.href)
+	}
+	nav := fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops">
+<head><title>%s</title></head>
+<body>
+  <nav epub:type="toc" id="toc">
+    <ol>
+%s    </ol>
+  </nav>
+</body>
+</html>
+`, xmlText(title), navItems.String())
+	if err := writeEPUBEntry(writer, "OEBPS/nav.xhtml", zip.Deflate, []byte(nav)); err != nil {
+		return nil, err
+	}
+
+	identifier := epubIdentifier(filePath)
+	ncx := fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<ncx xmlns="http://www.daisy.org/z3986/2005/ncx/" version="2005-1">
+  <head><meta name="dtb:uid" content="urn:uuid:%s"/></head>
+  <docTitle><text>%s</text></docTitle>
+  <navMap>
+%s  </navMap>
+</ncx>
+`, identifier, xmlText(title), ncxPoints.String())
+	if err := writeEPUBEntry(writer, "OEBPS/toc.ncx", zip.Deflate, []byte(ncx)); err != nil {
+		return nil, err
+	}
+
+	coverID := files[coverIndex].id + "-image"
+	var manifest, spine strings.Builder
+	manifest.WriteString("    <item id=\"nav\" href=\"nav.xhtml\" media-type=\"application/xhtml+xml\" properties=\"nav\"/>\n")
+	manifest.WriteString("    <item id=\"ncx\" href=\"toc.ncx\" media-type=\"application/x-dtbncx+xml\"/>\n")
+	for i, file := range files {
+		properties := ""
+		if i == coverIndex {
+			properties = " properties=\"cover-image\""
+		}
+		fmt.Fprintf(&manifest, "    <item id=\"%s-image\" href=\"%s\" media-type=\"%s\"%s/>\n", file.id, file.image, file.mediaType, properties)
+		fmt.Fprintf(&manifest, "    <item id=\"%s\" href=\"%s\" media-type=\"application/xhtml+xml\"/>\n", file.id, file.xhtml)
+		fmt.Fprintf(&spine, "    <itemref idref=\"%s\"/>\n", file.id)
+	}
+
+	// 原始分辨率使用设备分辨率，未指定设备时取最大的页面尺寸
+	width, height := options.Width, options.Height
+	if width <= 0 || height <= 0 {
+		for _, file := range files {
+			width, height = max(width, file.width), max(height, file.height)
+		}
+	}
+
+	progression := "ltr"
+	if rightToLeft {
+		progression = "rtl"
+	}
+	opf := fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="bookid" prefix="rendition: http://www.idpf.org/vocab/rendition/#">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+%s  </metadata>
+  <manifest>
+%s  </manifest>
+  <spine toc="ncx" page-progression-direction="%s">
+%s  </spine>
+</package>
+`, epubMetadata(identifier, title, info, rightToLeft, coverID, width, height), manifest.String(), progression, spine.String())
+	if err := writeEPUBEntry(writer, "OEBPS/content.opf", zip.Deflate, []byte(opf)); err != nil {
+		return nil, err
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("写入epub文件失败: %v", err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		return nil, fmt.Errorf("关闭临时文件失败: %v", err)
+	}
+	// 导出期间目标可能已被创建，重命名前再次确认不会覆盖
+	if _, err := os.Stat(outputPath); err == nil {
+		return nil, fmt.Errorf("目标文件已存在: %s", outputPath)
+	}
+	if err := os.Rename(tmpPath, outputPath); err != nil {
+		return nil, fmt.Errorf("保存epub文件失败: %v", err)
+	}
+	committed = true
+
+	fileSize := int64(0)
+	if stat, err := os.Stat(outputPath); err == nil {
+		fileSize = stat.Size()
+	}
+	fmt.Printf("已导出EPUB: %s\n", outputPath)
+	return map[string]interface{}{
+		"comicId":          comicID,
+		"outputPath":       outputPath,
+		"pageCount":        len(files),
+		"fileSize":         fileSize,
+		"readingDirection": direction,
+	}, nil
+}
+
+// GetEPUBDeviceProfiles 获取可选的阅读器设备配置
+func (a *App) GetEPUBDeviceProfiles() map[string]epubDeviceProfile {
+	return epubDeviceProfiles
+}
+
+// StartEPUBExport 在后台把漫画导出为固定版式EPUB，返回任务ID，通过 GetJob 查看每本的输出路径
+func (a *App) StartEPUBExport(comicIDs []int64, options EPUBExportOptions) (string, error) {
+	if a.db == nil {
+		return "", fmt.Errorf("数据库未初始化")
+	}
+	if len(comicIDs) == 0 {
+		return "", fmt.Errorf("请选择要导出的漫画")
+	}
+
+	options, err := resolveEPUBOptions(options)
+	if err != nil {
+		return "", err
+	}
+	if options.OutputDir != "" {
+		if err := os.MkdirAll(options.OutputDir, 0755); err != nil {
+			return "", fmt.Errorf("创建输出目录失败: %v", err)
+		}
+	}
+
+	return a.startJob("epub", len(comicIDs), func(ctx context.Context, job *backgroundJob) error {
+		for _, comicID := range comicIDs {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			result, err := a.exportComicToEPUB(ctx, comicID, options)
+			if err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				fmt.Printf("导出EPUB失败 %d: %v\n", comicID, err)
+				result = map[string]interface{}{
+					"comicId": comicID,
+					"error":   err.Error(),
+				}
+			}
+			job.advance(fmt.Sprintf("已导出漫画 %d", comicID), result)
+		}
+		return nil
+	}), nil
+}
@@ -0,0 +1,276 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// fileNameParserSettingKey 文件名解析规则在settings表中的键
+const fileNameParserSettingKey = "filename_parser"
+
+// FileNameParserConfig 文件名解析规则，每个正则的第一个捕获组为要提取的值
+type FileNameParserConfig struct {
+	GroupPattern    string   `json:"groupPattern"`
+	YearPattern     string   `json:"yearPattern"`
+	TagPattern      string   `json:"tagPattern"`
+	VolumePatterns  []string `json:"volumePatterns"`
+	ChapterPatterns []string `json:"chapterPatterns"`
+}
+
+// ParsedFileName 从文件名中解析出的信息
+type ParsedFileName struct {
+	Series  string   `json:"series"`
+	Volume  string   `json:"volume"`
+	Chapter string   `json:"chapter"`
+	Year    int      `json:"year"`
+	Group   string   `json:"group"`
+	Tags    []string `json:"tags"`
+}
+
+// defaultFileNameParserConfig 默认规则，适配 [Group] Title - Vol.03 Ch.021 (2019) [Digital] 这类汉化/扫图命名
+func defaultFileNameParserConfig() FileNameParserConfig {
+	return FileNameParserConfig{
+		GroupPattern: `^\s*[\[【]([^\]】]+)[\]】]`,
+		YearPattern:  `[\(\[]((?:19|20)\d{2})[\)\]]`,
+		TagPattern:   `[\[\(【]([^\]\)】]*)[\]\)】]`,
+		// 不用\b：下划线属于单词字符，Title_v02_c015 这类命名中\b无法匹配
+		VolumePatterns: []string{
+			`(?i)(?:^|[\s_.\-\[(])v(?:ol(?:ume)?)?\.?\s*(\d+(?:\.\d+)?)`,
+			`第\s*(\d+)\s*[卷巻册冊]`,
+		},
+		ChapterPatterns: []string{
+			`(?i)(?:^|[\s_.\-\[(])(?:ch(?:apter)?|c)\.?\s*(\d+(?:\.\d+)?)`,
+			`第\s*(\d+(?:\.\d+)?)\s*[话話回]`,
+			`#(\d+(?:\.\d+)?)`,
+			`(?:^|[\s_-])(\d+(?:\.\d+)?)\s*$`,
+		},
+	}
+}
+
+// fileNameParser 编译后的文件名解析规则
+type fileNameParser struct {
+	config   FileNameParserConfig
+	group    *regexp.Regexp
+	year     *regexp.Regexp
+	tag      *regexp.Regexp
+	volumes  []*regexp.Regexp
+	chapters []*regexp.Regexp
+}
+
+// compileParserPattern 编译单个规则，要求至少包含一个捕获组
+func compileParserPattern(pattern string) (*regexp.Regexp, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("无效的正则表达式 %s: %v", pattern, err)
+	}
+	if re.NumSubexp() < 1 {
+		return nil, fmt.Errorf("正则表达式缺少捕获组: %s", pattern)
+	}
+	return re, nil
+}
+
+// newFileNameParser 根据配置编译解析器，空规则表示不提取该字段
+func newFileNameParser(config FileNameParserConfig) (*fileNameParser, error) {
+	parser := &fileNameParser{config: config}
+
+	var err error
+	single := []struct {
+		pattern string
+		target  **regexp.Regexp
+	}{
+		{config.GroupPattern, &parser.group},
+		{config.YearPattern, &parser.year},
+		{config.TagPattern, &parser.tag},
+	}
+	for _, item := range single {
+		if item.pattern == "" {
+			continue
+		}
+		if *item.target, err = compileParserPattern(item.pattern); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, pattern := range config.VolumePatterns {
+		re, err := compileParserPattern(pattern)
+		if err != nil {
+			return nil, err
+		}
+		parser.volumes = append(parser.volumes, re)
+	}
+	for _, pattern := range config.ChapterPatterns {
+		re, err := compileParserPattern(pattern)
+		if err != nil {
+			return nil, err
+		}
+		parser.chapters = append(parser.chapters, re)
+	}
+
+	return parser, nil
+}
+
+// firstSubmatch 返回第一个非空捕获组
+func firstSubmatch(s string, loc []int) string {
+	for i := 2; i+1 < len(loc); i += 2 {
+		if loc[i] >= 0 && loc[i+1] > loc[i] {
+			return s[loc[i]:loc[i+1]]
+		}
+	}
+	return ""
+}
+
+// findFirst 依次尝试规则，返回第一个匹配的值及其在字符串中的位置
+func findFirst(patterns []*regexp.Regexp, s string) (string, []int) {
+	for _, re := range patterns {
+		if loc := re.FindStringSubmatchIndex(s); loc != nil {
+			if value := firstSubmatch(s, loc); value != "" {
+				return value, loc[:2]
+			}
+		}
+	}
+	return "", nil
+}
+
+// blankOut 用空格覆盖已匹配的部分，保持其余字符的位置不变
+func blankOut(s string, span []int) string {
+	return s[:span[0]] + strings.Repeat(" ", span[1]-span[0]) + s[span[1]:]
+}
+
+// parse 解析文件名（不含目录）
+func (p *fileNameParser) parse(fileName string) ParsedFileName {
+	name := strings.TrimSuffix(fileName, filepath.Ext(fileName))
+	var result ParsedFileName
+
+	// 开头的 [Group]
+	if p.group != nil {
+		if loc := p.group.FindStringSubmatchIndex(name); loc != nil {
+			result.Group = strings.TrimSpace(firstSubmatch(name, loc))
+			name = blankOut(name, loc[:2])
+		}
+	}
+
+	// (2019) 年份
+	if p.year != nil {
+		if loc := p.year.FindStringSubmatchIndex(name); loc != nil {
+			result.Year, _ = strconv.Atoi(firstSubmatch(name, loc))
+			name = blankOut(name, loc[:2])
+		}
+	}
+
+	// 其余括号内容作为版本标签，如 [Digital]
+	if p.tag != nil {
+		for _, loc := range p.tag.FindAllStringSubmatchIndex(name, -1) {
+			if tag := strings.TrimSpace(firstSubmatch(name, loc)); tag != "" {
+				result.Tags = append(result.Tags, tag)
+			}
+		}
+		name = p.tag.ReplaceAllStringFunc(name, func(m string) string {
+			return strings.Repeat(" ", len(m))
+		})
+	}
+
+	// 系列名取卷号、话数之前的部分
+	seriesEnd := len(name)
+	var volumeSpan []int
+	result.Volume, volumeSpan = findFirst(p.volumes, name)
+	rest := name
+	if volumeSpan != nil {
+		seriesEnd = volumeSpan[0]
+		rest = blankOut(name, volumeSpan)
+	}
+
+	var chapterSpan []int
+	result.Chapter, chapterSpan = findFirst(p.chapters, rest)
+	if chapterSpan != nil && chapterSpan[0] < seriesEnd {
+		seriesEnd = chapterSpan[0]
+	}
+
+	result.Series = cleanSeriesTitle(strings.Join(strings.Fields(name[:seriesEnd]), " "))
+	if result.Series == "" {
+		// 文件名只有话数时退回完整文件名
+		result.Series = cleanSeriesTitle(strings.Join(strings.Fields(name), " "))
+	}
+
+	return result
+}
+
+// getFileNameParser 获取当前生效的解析器，首次调用时从配置中加载
+func (a *App) getFileNameParser() *fileNameParser {
+	a.parserMu.Lock()
+	defer a.parserMu.Unlock()
+
+	if a.fileNameParser != nil {
+		return a.fileNameParser
+	}
+
+	config := defaultFileNameParserConfig()
+	if a.db != nil {
+		if _, err := a.loadSetting(fileNameParserSettingKey, &config); err != nil {
+			fmt.Printf("读取文件名解析规则失败，使用默认规则: %v\n", err)
+			config = defaultFileNameParserConfig()
+		}
+	}
+
+	parser, err := newFileNameParser(config)
+	if err != nil {
+		fmt.Printf("文件名解析规则无效，使用默认规则: %v\n", err)
+		parser, _ = newFileNameParser(defaultFileNameParserConfig())
+	}
+
+	a.fileNameParser = parser
+	return parser
+}
+
+// parseFileName 使用当前规则解析文件路径
+func (a *App) parseFileName(filePath string) ParsedFileName {
+	return a.getFileNameParser().parse(filepath.Base(filePath))
+}
+
+// saveParsedFileName 将文件名解析出的年份、汉化组和版本标签保存到漫画信息中
+func (a *App) saveParsedFileName(comicID int64, filePath string) error {
+	parsed := a.parseFileName(filePath)
+
+	_, err := a.db.Exec(`UPDATE comics SET release_year = ?, scan_group = ?, edition_tags = ? WHERE id = ?`,
+		parsed.Year, parsed.Group, strings.Join(parsed.Tags, ","), comicID)
+	if err != nil {
+		return fmt.Errorf("保存文件名解析结果失败: %v", err)
+	}
+
+	return nil
+}
+
+// GetFileNameParserConfig 获取当前的文件名解析规则
+func (a *App) GetFileNameParserConfig() FileNameParserConfig {
+	return a.getFileNameParser().config
+}
+
+// SetFileNameParserConfig 保存文件名解析规则，规则无效时返回错误
+func (a *App) SetFileNameParserConfig(config FileNameParserConfig) error {
+	parser, err := newFileNameParser(config)
+	if err != nil {
+		return err
+	}
+
+	if err := a.saveSetting(fileNameParserSettingKey, config); err != nil {
+		return err
+	}
+
+	a.parserMu.Lock()
+	a.fileNameParser = parser
+	a.parserMu.Unlock()
+
+	return nil
+}
+
+// ResetFileNameParserConfig 恢复默认的文件名解析规则
+func (a *App) ResetFileNameParserConfig() error {
+	return a.SetFileNameParserConfig(defaultFileNameParserConfig())
+}
+
+// PreviewFileNameParse 用当前规则解析文件名，用于调整规则时预览结果
+func (a *App) PreviewFileNameParse(fileName string) ParsedFileName {
+	return a.parseFileName(fileName)
+}
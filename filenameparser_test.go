@@ -0,0 +1,57 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestFileNameParserParse 默认规则解析常见的扫图、汉化命名
+func TestFileNameParserParse(t *testing.T) {
+	parser, err := newFileNameParser(defaultFileNameParserConfig())
+	if err != nil {
+		t.Fatalf("newFileNameParser: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		want ParsedFileName
+	}{
+		{"[Group] Title - Vol.03 Ch.021 (2019) [Digital].cbz", ParsedFileName{Series: "Title", Volume: "03", Chapter: "021", Year: 2019, Group: "Group", Tags: []string{"Digital"}}},
+		{"Title_v02_c015.cbz", ParsedFileName{Series: "Title", Volume: "02", Chapter: "015"}},
+		{"Title.v02.c015.zip", ParsedFileName{Series: "Title", Volume: "02", Chapter: "015"}},
+		{"Title v2.5 c10.5.cbz", ParsedFileName{Series: "Title", Volume: "2.5", Chapter: "10.5"}},
+		{"Catch Me v01.cbz", ParsedFileName{Series: "Catch Me", Volume: "01"}},
+		{"Chainsaw Man c001.cbz", ParsedFileName{Series: "Chainsaw Man", Chapter: "001"}},
+		{"Volcano Park.cbz", ParsedFileName{Series: "Volcano Park"}},
+		{"【汉化组】标题 第3卷 第12话.zip", ParsedFileName{Series: "标题", Volume: "3", Chapter: "12", Group: "汉化组"}},
+		{"标题 第12.5话.cbz", ParsedFileName{Series: "标题", Chapter: "12.5"}},
+		{"Title #7.cbz", ParsedFileName{Series: "Title", Chapter: "7"}},
+		{"Title - 012.cbz", ParsedFileName{Series: "Title", Chapter: "012"}},
+		{"015.cbz", ParsedFileName{Series: "015", Chapter: "015"}},
+		{"Title (2020) [Digital] [HD].cbz", ParsedFileName{Series: "Title", Year: 2020, Tags: []string{"Digital", "HD"}}},
+	}
+
+	for _, tt := range tests {
+		if got := parser.parse(tt.name); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parse(%q) = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// TestNewFileNameParserInvalid 无效的正则或缺少捕获组时返回错误
+func TestNewFileNameParserInvalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		config FileNameParserConfig
+	}{
+		{"invalid regex", FileNameParserConfig{GroupPattern: `[`}},
+		{"no capture group", FileNameParserConfig{YearPattern: `\d{4}`}},
+		{"invalid chapter pattern", FileNameParserConfig{ChapterPatterns: []string{`(\d+`}}},
+	}
+
+	for _, tt := range tests {
+		if _, err := newFileNameParser(tt.config); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+}
@@ -1,16 +1,177 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
+import {main} from '../models';
+
+export function AddBookmark(arg1:number,arg2:number,arg3:string,arg4:string,arg5:string):Promise<number>;
+
+export function AddComicToCollection(arg1:number,arg2:number):Promise<void>;
+
+export function AddTagToComic(arg1:number,arg2:string):Promise<void>;
+
+export function CancelJob(arg1:string):Promise<void>;
+
+export function ClearFinishedJobs():Promise<void>;
+
+export function ClearImageCache():Promise<void>;
+
+export function ConvertComicToCBZ(arg1:number,arg2:main.ConvertOptions):Promise<Record<string, any>>;
+
+export function ConvertToCBZ(arg1:string,arg2:main.ConvertOptions):Promise<Record<string, any>>;
+
+export function CreateCollection(arg1:string):Promise<number>;
+
+export function CreateSmartCollection(arg1:string,arg2:main.SmartCollectionRules):Promise<number>;
+
+export function DeleteBookmark(arg1:number):Promise<void>;
+
+export function DeleteCollection(arg1:number):Promise<void>;
 
 export function DeleteComicFromDatabase(arg1:number):Promise<void>;
 
+export function DeleteTag(arg1:number):Promise<void>;
+
+export function EndReadingSession(arg1:number,arg2:number):Promise<void>;
+
+export function GetAllBookmarks():Promise<Array<Record<string, any>>>;
+
+export function GetBrokenComics():Promise<Array<Record<string, any>>>;
+
+export function GetCollectionComics(arg1:number):Promise<Array<Record<string, any>>>;
+
+export function GetCollections():Promise<Array<Record<string, any>>>;
+
+export function GetComicBookmarks(arg1:number):Promise<Array<Record<string, any>>>;
+
+export function GetComicChapters(arg1:number):Promise<Array<Record<string, any>>>;
+
+export function GetComicCover(arg1:number):Promise<Record<string, any>>;
+
+export function GetComicInfo(arg1:number):Promise<main.ComicInfo>;
+
+export function GetComicPages(arg1:number):Promise<Array<Record<string, any>>>;
+
+export function GetComicTags(arg1:number):Promise<Array<string>>;
+
+export function GetComicUserData(arg1:number):Promise<Record<string, any>>;
+
+export function GetComicsByTag(arg1:string):Promise<Array<Record<string, any>>>;
+
 export function GetComicsFromDatabase():Promise<Array<Record<string, any>>>;
 
+export function GetEPUBDeviceProfiles():Promise<Record<string, main.epubDeviceProfile>>;
+
+export function GetFileNameParserConfig():Promise<main.FileNameParserConfig>;
+
+export function GetIgnoreRules():Promise<main.IgnoreRulesConfig>;
+
 export function GetImageBase64(arg1:string):Promise<string>;
 
 export function GetImageData(arg1:string):Promise<Array<number>>;
 
+export function GetJob(arg1:string):Promise<Record<string, any>>;
+
+export function GetReaderData(arg1:number):Promise<Record<string, any>>;
+
+export function GetReadingHistory(arg1:number):Promise<Array<Record<string, any>>>;
+
+export function GetReadingStats(arg1:number):Promise<Record<string, any>>;
+
+export function GetSeriesComics(arg1:number):Promise<Array<Record<string, any>>>;
+
+export function GetSeriesList():Promise<Array<Record<string, any>>>;
+
+export function GetSortLocale():Promise<string>;
+
+export function GetSplitPages(arg1:number):Promise<Array<Record<string, any>>>;
+
+export function GetTags():Promise<Array<Record<string, any>>>;
+
+export function GetTwoPageLayout(arg1:number,arg2:number):Promise<Record<string, any>>;
+
 export function Greet(arg1:string):Promise<string>;
 
 export function HandleFileDrop(arg1:Array<string>):Promise<void>;
 
+export function ListJobs():Promise<Array<Record<string, any>>>;
+
+export function PreviewFileNameParse(arg1:string):Promise<main.ParsedFileName>;
+
+export function PreviewIgnoreRules(arg1:string,arg2:string):Promise<Record<string, any>>;
+
+export function PreviewSmartCollection(arg1:main.SmartCollectionRules):Promise<Array<Record<string, any>>>;
+
+export function QueryComics(arg1:main.LibraryQuery):Promise<Array<Record<string, any>>>;
+
+export function RecordPageTurn(arg1:number,arg2:number):Promise<void>;
+
+export function RegenerateThumbnails():Promise<number>;
+
+export function RegroupSeries():Promise<void>;
+
+export function ReindexComicPages(arg1:number):Promise<void>;
+
+export function RemoveComicFromCollection(arg1:number,arg2:number):Promise<void>;
+
+export function RemoveTagFromComic(arg1:number,arg2:string):Promise<void>;
+
+export function RenameCollection(arg1:number,arg2:string):Promise<void>;
+
+export function RenameTag(arg1:number,arg2:string):Promise<void>;
+
+export function ReorderCollection(arg1:number,arg2:Array<number>):Promise<void>;
+
+export function ResetComicCover(arg1:number):Promise<void>;
+
+export function ResetFileNameParserConfig():Promise<void>;
+
+export function ResetIgnoreRules():Promise<void>;
+
+export function ResolveDuplicates(arg1:number,arg2:Array<number>,arg3:boolean):Promise<void>;
+
 export function SearchComicsInDatabase(arg1:string):Promise<Array<Record<string, any>>>;
+
+export function SetComicCoverImage(arg1:number,arg2:string):Promise<void>;
+
+export function SetComicCoverPage(arg1:number,arg2:number):Promise<void>;
+
+export function SetComicFavorite(arg1:number,arg2:boolean):Promise<void>;
+
+export function SetComicNotes(arg1:number,arg2:string):Promise<void>;
+
+export function SetComicRating(arg1:number,arg2:number):Promise<void>;
+
+export function SetComicRead(arg1:number,arg2:boolean):Promise<void>;
+
+export function SetComicReadingMode(arg1:number,arg2:string):Promise<void>;
+
+export function SetFileNameParserConfig(arg1:main.FileNameParserConfig):Promise<void>;
+
+export function SetIgnoreRules(arg1:main.IgnoreRulesConfig):Promise<void>;
+
+export function SetSeriesReadingMode(arg1:number,arg2:string):Promise<void>;
+
+export function SetSortLocale(arg1:string):Promise<void>;
+
+export function StartDuplicateScan():Promise<string>;
+
+export function StartEPUBExport(arg1:Array<number>,arg2:main.EPUBExportOptions):Promise<string>;
+
+export function StartNearDuplicateScan(arg1:number):Promise<string>;
+
+export function StartReadingSession(arg1:number,arg2:number):Promise<number>;
+
+export function StartRepack(arg1:Array<number>,arg2:main.RepackOptions):Promise<string>;
+
+export function StartVerifyLibrary():Promise<string>;
+
+export function UpdateBookmark(arg1:number,arg2:string,arg3:string):Promise<void>;
+
+export function UpdateReadingProgress(arg1:number,arg2:number,arg3:number):Promise<void>;
+
+export function UpdateSmartCollectionRules(arg1:number,arg2:main.SmartCollectionRules):Promise<void>;
+
+export function VerifyComic(arg1:number):Promise<Record<string, any>>;
+
+export function WriteComicInfo(arg1:number,arg2:main.ComicInfo,arg3:boolean):Promise<Record<string, any>>;
+
+export function WriteComicInfoBatch(arg1:Array<main.ComicInfoUpdate>,arg2:boolean):Promise<Array<Record<string, any>>>;
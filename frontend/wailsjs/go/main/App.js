@@ -2,14 +2,130 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 
+export function AddBookmark(arg1, arg2, arg3, arg4, arg5) {
+  return window['go']['main']['App']['AddBookmark'](arg1, arg2, arg3, arg4, arg5);
+}
+
+export function AddComicToCollection(arg1, arg2) {
+  return window['go']['main']['App']['AddComicToCollection'](arg1, arg2);
+}
+
+export function AddTagToComic(arg1, arg2) {
+  return window['go']['main']['App']['AddTagToComic'](arg1, arg2);
+}
+
+export function CancelJob(arg1) {
+  return window['go']['main']['App']['CancelJob'](arg1);
+}
+
+export function ClearFinishedJobs() {
+  return window['go']['main']['App']['ClearFinishedJobs']();
+}
+
+export function ClearImageCache() {
+  return window['go']['main']['App']['ClearImageCache']();
+}
+
+export function ConvertComicToCBZ(arg1, arg2) {
+  return window['go']['main']['App']['ConvertComicToCBZ'](arg1, arg2);
+}
+
+export function ConvertToCBZ(arg1, arg2) {
+  return window['go']['main']['App']['ConvertToCBZ'](arg1, arg2);
+}
+
+export function CreateCollection(arg1) {
+  return window['go']['main']['App']['CreateCollection'](arg1);
+}
+
+export function CreateSmartCollection(arg1, arg2) {
+  return window['go']['main']['App']['CreateSmartCollection'](arg1, arg2);
+}
+
+export function DeleteBookmark(arg1) {
+  return window['go']['main']['App']['DeleteBookmark'](arg1);
+}
+
+export function DeleteCollection(arg1) {
+  return window['go']['main']['App']['DeleteCollection'](arg1);
+}
+
 export function DeleteComicFromDatabase(arg1) {
   return window['go']['main']['App']['DeleteComicFromDatabase'](arg1);
 }
 
+export function DeleteTag(arg1) {
+  return window['go']['main']['App']['DeleteTag'](arg1);
+}
+
+export function EndReadingSession(arg1, arg2) {
+  return window['go']['main']['App']['EndReadingSession'](arg1, arg2);
+}
+
+export function GetAllBookmarks() {
+  return window['go']['main']['App']['GetAllBookmarks']();
+}
+
+export function GetBrokenComics() {
+  return window['go']['main']['App']['GetBrokenComics']();
+}
+
+export function GetCollectionComics(arg1) {
+  return window['go']['main']['App']['GetCollectionComics'](arg1);
+}
+
+export function GetCollections() {
+  return window['go']['main']['App']['GetCollections']();
+}
+
+export function GetComicBookmarks(arg1) {
+  return window['go']['main']['App']['GetComicBookmarks'](arg1);
+}
+
+export function GetComicChapters(arg1) {
+  return window['go']['main']['App']['GetComicChapters'](arg1);
+}
+
+export function GetComicCover(arg1) {
+  return window['go']['main']['App']['GetComicCover'](arg1);
+}
+
+export function GetComicInfo(arg1) {
+  return window['go']['main']['App']['GetComicInfo'](arg1);
+}
+
+export function GetComicPages(arg1) {
+  return window['go']['main']['App']['GetComicPages'](arg1);
+}
+
+export function GetComicTags(arg1) {
+  return window['go']['main']['App']['GetComicTags'](arg1);
+}
+
+export function GetComicUserData(arg1) {
+  return window['go']['main']['App']['GetComicUserData'](arg1);
+}
+
+export function GetComicsByTag(arg1) {
+  return window['go']['main']['App']['GetComicsByTag'](arg1);
+}
+
 export function GetComicsFromDatabase() {
   return window['go']['main']['App']['GetComicsFromDatabase']();
 }
 
+export function GetEPUBDeviceProfiles() {
+  return window['go']['main']['App']['GetEPUBDeviceProfiles']();
+}
+
+export function GetFileNameParserConfig() {
+  return window['go']['main']['App']['GetFileNameParserConfig']();
+}
+
+export function GetIgnoreRules() {
+  return window['go']['main']['App']['GetIgnoreRules']();
+}
+
 export function GetImageBase64(arg1) {
   return window['go']['main']['App']['GetImageBase64'](arg1);
 }
@@ -18,6 +134,46 @@ export function GetImageData(arg1) {
   return window['go']['main']['App']['GetImageData'](arg1);
 }
 
+export function GetJob(arg1) {
+  return window['go']['main']['App']['GetJob'](arg1);
+}
+
+export function GetReaderData(arg1) {
+  return window['go']['main']['App']['GetReaderData'](arg1);
+}
+
+export function GetReadingHistory(arg1) {
+  return window['go']['main']['App']['GetReadingHistory'](arg1);
+}
+
+export function GetReadingStats(arg1) {
+  return window['go']['main']['App']['GetReadingStats'](arg1);
+}
+
+export function GetSeriesComics(arg1) {
+  return window['go']['main']['App']['GetSeriesComics'](arg1);
+}
+
+export function GetSeriesList() {
+  return window['go']['main']['App']['GetSeriesList']();
+}
+
+export function GetSortLocale() {
+  return window['go']['main']['App']['GetSortLocale']();
+}
+
+export function GetSplitPages(arg1) {
+  return window['go']['main']['App']['GetSplitPages'](arg1);
+}
+
+export function GetTags() {
+  return window['go']['main']['App']['GetTags']();
+}
+
+export function GetTwoPageLayout(arg1, arg2) {
+  return window['go']['main']['App']['GetTwoPageLayout'](arg1, arg2);
+}
+
 export function Greet(arg1) {
   return window['go']['main']['App']['Greet'](arg1);
 }
@@ -26,6 +182,170 @@ export function HandleFileDrop(arg1) {
   return window['go']['main']['App']['HandleFileDrop'](arg1);
 }
 
+export function ListJobs() {
+  return window['go']['main']['App']['ListJobs']();
+}
+
+export function PreviewFileNameParse(arg1) {
+  return window['go']['main']['App']['PreviewFileNameParse'](arg1);
+}
+
+export function PreviewIgnoreRules(arg1, arg2) {
+  return window['go']['main']['App']['PreviewIgnoreRules'](arg1, arg2);
+}
+
+export function PreviewSmartCollection(arg1) {
+  return window['go']['main']['App']['PreviewSmartCollection'](arg1);
+}
+
+export function QueryComics(arg1) {
+  return window['go']['main']['App']['QueryComics'](arg1);
+}
+
+export function RecordPageTurn(arg1, arg2) {
+  return window['go']['main']['App']['RecordPageTurn'](arg1, arg2);
+}
+
+export function RegenerateThumbnails() {
+  return window['go']['main']['App']['RegenerateThumbnails']();
+}
+
+export function RegroupSeries() {
+  return window['go']['main']['App']['RegroupSeries']();
+}
+
+export function ReindexComicPages(arg1) {
+  return window['go']['main']['App']['ReindexComicPages'](arg1);
+}
+
+export function RemoveComicFromCollection(arg1, arg2) {
+  return window['go']['main']['App']['RemoveComicFromCollection'](arg1, arg2);
+}
+
+export function RemoveTagFromComic(arg1, arg2) {
+  return window['go']['main']['App']['RemoveTagFromComic'](arg1, arg2);
+}
+
+export function RenameCollection(arg1, arg2) {
+  return window['go']['main']['App']['RenameCollection'](arg1, arg2);
+}
+
+export function RenameTag(arg1, arg2) {
+  return window['go']['main']['App']['RenameTag'](arg1, arg2);
+}
+
+export function ReorderCollection(arg1, arg2) {
+  return window['go']['main']['App']['ReorderCollection'](arg1, arg2);
+}
+
+export function ResetComicCover(arg1) {
+  return window['go']['main']['App']['ResetComicCover'](arg1);
+}
+
+export function ResetFileNameParserConfig() {
+  return window['go']['main']['App']['ResetFileNameParserConfig']();
+}
+
+export function ResetIgnoreRules() {
+  return window['go']['main']['App']['ResetIgnoreRules']();
+}
+
+export function ResolveDuplicates(arg1, arg2, arg3) {
+  return window['go']['main']['App']['ResolveDuplicates'](arg1, arg2, arg3);
+}
+
 export function SearchComicsInDatabase(arg1) {
   return window['go']['main']['App']['SearchComicsInDatabase'](arg1);
 }
+
+export function SetComicCoverImage(arg1, arg2) {
+  return window['go']['main']['App']['SetComicCoverImage'](arg1, arg2);
+}
+
+export function SetComicCoverPage(arg1, arg2) {
+  return window['go']['main']['App']['SetComicCoverPage'](arg1, arg2);
+}
+
+export function SetComicFavorite(arg1, arg2) {
+  return window['go']['main']['App']['SetComicFavorite'](arg1, arg2);
+}
+
+export function SetComicNotes(arg1, arg2) {
+  return window['go']['main']['App']['SetComicNotes'](arg1, arg2);
+}
+
+export function SetComicRating(arg1, arg2) {
+  return window['go']['main']['App']['SetComicRating'](arg1, arg2);
+}
+
+export function SetComicRead(arg1, arg2) {
+  return window['go']['main']['App']['SetComicRead'](arg1, arg2);
+}
+
+export function SetComicReadingMode(arg1, arg2) {
+  return window['go']['main']['App']['SetComicReadingMode'](arg1, arg2);
+}
+
+export function SetFileNameParserConfig(arg1) {
+  return window['go']['main']['App']['SetFileNameParserConfig'](arg1);
+}
+
+export function SetIgnoreRules(arg1) {
+  return window['go']['main']['App']['SetIgnoreRules'](arg1);
+}
+
+export function SetSeriesReadingMode(arg1, arg2) {
+  return window['go']['main']['App']['SetSeriesReadingMode'](arg1, arg2);
+}
+
+export function SetSortLocale(arg1) {
+  return window['go']['main']['App']['SetSortLocale'](arg1);
+}
+
+export function StartDuplicateScan() {
+  return window['go']['main']['App']['StartDuplicateScan']();
+}
+
+export function StartEPUBExport(arg1, arg2) {
+  return window['go']['main']['App']['StartEPUBExport'](arg1, arg2);
+}
+
+export function StartNearDuplicateScan(arg1) {
+  return window['go']['main']['App']['StartNearDuplicateScan'](arg1);
+}
+
+export function StartReadingSession(arg1, arg2) {
+  return window['go']['main']['App']['StartReadingSession'](arg1, arg2);
+}
+
+export function StartRepack(arg1, arg2) {
+  return window['go']['main']['App']['StartRepack'](arg1, arg2);
+}
+
+export function StartVerifyLibrary() {
+  return window['go']['main']['App']['StartVerifyLibrary']();
+}
+
+export function UpdateBookmark(arg1, arg2, arg3) {
+  return window['go']['main']['App']['UpdateBookmark'](arg1, arg2, arg3);
+}
+
+export function UpdateReadingProgress(arg1, arg2, arg3) {
+  return window['go']['main']['App']['UpdateReadingProgress'](arg1, arg2, arg3);
+}
+
+export function UpdateSmartCollectionRules(arg1, arg2) {
+  return window['go']['main']['App']['UpdateSmartCollectionRules'](arg1, arg2);
+}
+
+export function VerifyComic(arg1) {
+  return window['go']['main']['App']['VerifyComic'](arg1);
+}
+
+export function WriteComicInfo(arg1, arg2, arg3) {
+  return window['go']['main']['App']['WriteComicInfo'](arg1, arg2, arg3);
+}
+
+export function WriteComicInfoBatch(arg1, arg2) {
+  return window['go']['main']['App']['WriteComicInfoBatch'](arg1, arg2);
+}
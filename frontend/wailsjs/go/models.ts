@@ -0,0 +1,372 @@
+export namespace main {
+	
+	export class ComicInfo {
+	    title: string;
+	    series: string;
+	    number: string;
+	    count: number;
+	    volume: number;
+	    summary: string;
+	    notes: string;
+	    year: number;
+	    month: number;
+	    day: number;
+	    writer: string;
+	    penciller: string;
+	    translator: string;
+	    publisher: string;
+	    genre: string;
+	    tags: string;
+	    web: string;
+	    pageCount: number;
+	    languageISO: string;
+	    manga: string;
+	    ageRating: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ComicInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.title = source["title"];
+	        this.series = source["series"];
+	        this.number = source["number"];
+	        this.count = source["count"];
+	        this.volume = source["volume"];
+	        this.summary = source["summary"];
+	        this.notes = source["notes"];
+	        this.year = source["year"];
+	        this.month = source["month"];
+	        this.day = source["day"];
+	        this.writer = source["writer"];
+	        this.penciller = source["penciller"];
+	        this.translator = source["translator"];
+	        this.publisher = source["publisher"];
+	        this.genre = source["genre"];
+	        this.tags = source["tags"];
+	        this.web = source["web"];
+	        this.pageCount = source["pageCount"];
+	        this.languageISO = source["languageISO"];
+	        this.manga = source["manga"];
+	        this.ageRating = source["ageRating"];
+	    }
+	}
+	export class ComicInfoUpdate {
+	    comicId: number;
+	    info: ComicInfo;
+	
+	    static createFrom(source: any = {}) {
+	        return new ComicInfoUpdate(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.comicId = source["comicId"];
+	        this.info = this.convertValues(source["info"], ComicInfo);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ConvertOptions {
+	    embedComicInfo: boolean;
+	    replaceSource: boolean;
+	    outputPath: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ConvertOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.embedComicInfo = source["embedComicInfo"];
+	        this.replaceSource = source["replaceSource"];
+	        this.outputPath = source["outputPath"];
+	    }
+	}
+	export class EPUBExportOptions {
+	    outputDir: string;
+	    device: string;
+	    width: number;
+	    height: number;
+	    grayscale: boolean;
+	    spreadMode: string;
+	    readingDirection: string;
+	    quality: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new EPUBExportOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.outputDir = source["outputDir"];
+	        this.device = source["device"];
+	        this.width = source["width"];
+	        this.height = source["height"];
+	        this.grayscale = source["grayscale"];
+	        this.spreadMode = source["spreadMode"];
+	        this.readingDirection = source["readingDirection"];
+	        this.quality = source["quality"];
+	    }
+	}
+	export class FileNameParserConfig {
+	    groupPattern: string;
+	    yearPattern: string;
+	    tagPattern: string;
+	    volumePatterns: string[];
+	    chapterPatterns: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new FileNameParserConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.groupPattern = source["groupPattern"];
+	        this.yearPattern = source["yearPattern"];
+	        this.tagPattern = source["tagPattern"];
+	        this.volumePatterns = source["volumePatterns"];
+	        this.chapterPatterns = source["chapterPatterns"];
+	    }
+	}
+	export class IgnoreRulesConfig {
+	    patterns: string[];
+	    libraries: Record<string, Array<string>>;
+	    excludeCreditPages: boolean;
+	    creditPatterns: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new IgnoreRulesConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.patterns = source["patterns"];
+	        this.libraries = source["libraries"];
+	        this.excludeCreditPages = source["excludeCreditPages"];
+	        this.creditPatterns = source["creditPatterns"];
+	    }
+	}
+	export class LibraryQuery {
+	    keyword: string;
+	    favoritesOnly: boolean;
+	    unreadOnly: boolean;
+	    minRating: number;
+	    hasNotes: boolean;
+	    tag: string;
+	    sortBy: string;
+	    sortDesc: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new LibraryQuery(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.keyword = source["keyword"];
+	        this.favoritesOnly = source["favoritesOnly"];
+	        this.unreadOnly = source["unreadOnly"];
+	        this.minRating = source["minRating"];
+	        this.hasNotes = source["hasNotes"];
+	        this.tag = source["tag"];
+	        this.sortBy = source["sortBy"];
+	        this.sortDesc = source["sortDesc"];
+	    }
+	}
+	export class ParsedFileName {
+	    series: string;
+	    volume: string;
+	    chapter: string;
+	    year: number;
+	    group: string;
+	    tags: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new ParsedFileName(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.series = source["series"];
+	        this.volume = source["volume"];
+	        this.chapter = source["chapter"];
+	        this.year = source["year"];
+	        this.group = source["group"];
+	        this.tags = source["tags"];
+	    }
+	}
+	export class RepackOptions {
+	    format: string;
+	    quality: number;
+	    lossless: boolean;
+	    maxDimension: number;
+	    recompressLossy: boolean;
+	    keepBackup: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new RepackOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.format = source["format"];
+	        this.quality = source["quality"];
+	        this.lossless = source["lossless"];
+	        this.maxDimension = source["maxDimension"];
+	        this.recompressLossy = source["recompressLossy"];
+	        this.keepBackup = source["keepBackup"];
+	    }
+	}
+	export class SmartCollectionRule {
+	    field: string;
+	    value: string;
+	    negate: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new SmartCollectionRule(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.field = source["field"];
+	        this.value = source["value"];
+	        this.negate = source["negate"];
+	    }
+	}
+	export class SmartCollectionRules {
+	    match: string;
+	    rules: SmartCollectionRule[];
+	
+	    static createFrom(source: any = {}) {
+	        return new SmartCollectionRules(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.match = source["match"];
+	        this.rules = this.convertValues(source["rules"], SmartCollectionRule);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class comicInfoElement {
+	    XMLName: xml.Name;
+	    Attrs: xml.Attr[];
+	    Content: number[];
+	
+	    static createFrom(source: any = {}) {
+	        return new comicInfoElement(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.XMLName = this.convertValues(source["XMLName"], xml.Name);
+	        this.Attrs = this.convertValues(source["Attrs"], xml.Attr);
+	        this.Content = source["Content"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
+export namespace xml {
+	
+	export class Name {
+	    Space: string;
+	    Local: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Name(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Space = source["Space"];
+	        this.Local = source["Local"];
+	    }
+	}
+	export class Attr {
+	    Name: Name;
+	    Value: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Attr(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Name = this.convertValues(source["Name"], Name);
+	        this.Value = source["Value"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
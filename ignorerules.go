@@ -0,0 +1,262 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ignoreRulesSettingKey 忽略规则在settings表中的键
+const ignoreRulesSettingKey = "ignore_rules"
+
+// regexRulePrefix 以该前缀开头的规则按正则表达式匹配，其余按通配符匹配
+const regexRulePrefix = "re:"
+
+// IgnoreRulesConfig 扫描页面时的忽略规则
+// 通配符规则不含 / 时匹配路径中的任意一级，含 / 时匹配完整的相对路径；re: 开头的规则用正则匹配完整的相对路径
+type IgnoreRulesConfig struct {
+	Patterns           []string            `json:"patterns"`
+	Libraries          map[string][]string `json:"libraries"` // 库目录 -> 只对该目录下漫画生效的规则
+	ExcludeCreditPages bool                `json:"excludeCreditPages"`
+	CreditPatterns     []string            `json:"creditPatterns"` // 匹配页面文件名（不含扩展名）的正则
+}
+
+// defaultIgnoreRulesConfig 默认忽略系统生成的元数据文件，汉化组招募页和广告页默认保留
+func defaultIgnoreRulesConfig() IgnoreRulesConfig {
+	return IgnoreRulesConfig{
+		Patterns: []string{
+			"__MACOSX",
+			"._*",
+			".DS_Store",
+			"Thumbs.db",
+			"ehthumbs.db",
+			"desktop.ini",
+			"$RECYCLE.BIN",
+		},
+		Libraries:          map[string][]string{},
+		ExcludeCreditPages: false,
+		CreditPatterns: []string{
+			`(?i)(^|[^a-z])(credits?|recruit(ment|ing)?|scanlators?|ads?|advert(isement)?s?|sponsors?)([^a-z]|$)`,
+			`招募|广告|廣告|宣传|宣傳|汉化组|漢化組|工作组|工作組|嵌字|赞助|贊助`,
+		},
+	}
+}
+
+// ignoreMatcher 编译后的忽略规则
+type ignoreMatcher struct {
+	globs   []string
+	regexes []*regexp.Regexp
+	credits []*regexp.Regexp
+}
+
+// compileIgnorePatterns 将规则加入匹配器，通配符统一转小写以忽略大小写
+func (m *ignoreMatcher) compileIgnorePatterns(patterns []string) error {
+	for _, pattern := range patterns {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+
+		if strings.HasPrefix(pattern, regexRulePrefix) {
+			re, err := regexp.Compile(strings.TrimPrefix(pattern, regexRulePrefix))
+			if err != nil {
+				return fmt.Errorf("无效的正则表达式 %s: %v", pattern, err)
+			}
+			m.regexes = append(m.regexes, re)
+			continue
+		}
+
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("无效的通配符 %s: %v", pattern, err)
+		}
+		m.globs = append(m.globs, strings.ToLower(pattern))
+	}
+
+	return nil
+}
+
+// newIgnoreMatcher 编译全局规则和指定库目录的规则
+func newIgnoreMatcher(config IgnoreRulesConfig, libraryPatterns ...[]string) (*ignoreMatcher, error) {
+	m := &ignoreMatcher{}
+	if err := m.compileIgnorePatterns(config.Patterns); err != nil {
+		return nil, err
+	}
+	for _, patterns := range libraryPatterns {
+		if err := m.compileIgnorePatterns(patterns); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, pattern := range config.CreditPatterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("无效的正则表达式 %s: %v", pattern, err)
+		}
+		if config.ExcludeCreditPages {
+			m.credits = append(m.credits, re)
+		}
+	}
+
+	return m, nil
+}
+
+// ignored 判断相对路径（以 / 分隔）是否被忽略
+func (m *ignoreMatcher) ignored(name string) bool {
+	if m == nil {
+		return false
+	}
+
+	name = strings.Trim(strings.ReplaceAll(name, "\\", "/"), "/")
+	lower := strings.ToLower(name)
+	parts := strings.Split(lower, "/")
+
+	for _, glob := range m.globs {
+		if strings.Contains(glob, "/") {
+			if matched, _ := path.Match(glob, lower); matched {
+				return true
+			}
+			continue
+		}
+		for _, part := range parts {
+			if matched, _ := path.Match(glob, part); matched {
+				return true
+			}
+		}
+	}
+
+	for _, re := range m.regexes {
+		if re.MatchString(name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isCreditPage 判断页面是否为汉化组招募页、广告页等，未开启排除时总是返回false
+func (m *ignoreMatcher) isCreditPage(name string) bool {
+	if m == nil {
+		return false
+	}
+
+	base := path.Base(strings.ReplaceAll(name, "\\", "/"))
+	base = strings.TrimSuffix(base, path.Ext(base))
+	for _, re := range m.credits {
+		if re.MatchString(base) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// skipEntry 判断扫描时是否跳过该条目
+func (m *ignoreMatcher) skipEntry(name string) bool {
+	return m.ignored(name) || m.isCreditPage(name)
+}
+
+// getIgnoreRules 获取当前的忽略规则，首次调用时从配置中加载
+func (a *App) getIgnoreRules() IgnoreRulesConfig {
+	a.ignoreMu.Lock()
+	defer a.ignoreMu.Unlock()
+
+	if a.ignoreRules != nil {
+		return *a.ignoreRules
+	}
+
+	config := defaultIgnoreRulesConfig()
+	if a.db != nil {
+		if _, err := a.loadSetting(ignoreRulesSettingKey, &config); err != nil {
+			fmt.Printf("读取忽略规则失败，使用默认规则: %v\n", err)
+			config = defaultIgnoreRulesConfig()
+		}
+	}
+
+	a.ignoreRules = &config
+	return config
+}
+
+// ignoreMatcherFor 获取对指定漫画路径生效的规则：全局规则加上所在库目录的规则
+func (a *App) ignoreMatcherFor(comicPath string) *ignoreMatcher {
+	config := a.getIgnoreRules()
+
+	var libraryPatterns [][]string
+	for root, patterns := range config.Libraries {
+		if isUnderDirectory(comicPath, root) {
+			libraryPatterns = append(libraryPatterns, patterns)
+		}
+	}
+
+	m, err := newIgnoreMatcher(config, libraryPatterns...)
+	if err != nil {
+		fmt.Printf("忽略规则无效，使用默认规则: %v\n", err)
+		m, _ = newIgnoreMatcher(defaultIgnoreRulesConfig())
+	}
+
+	return m
+}
+
+// isUnderDirectory 判断路径是否位于目录之下（包含目录本身）
+func isUnderDirectory(filePath, dir string) bool {
+	rel, err := filepath.Rel(filepath.Clean(dir), filepath.Clean(filePath))
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
+
+// relativeEntryPath 获取文件夹中文件的相对路径，以 / 分隔
+func relativeEntryPath(folderPath, filePath string) string {
+	rel, err := filepath.Rel(folderPath, filePath)
+	if err != nil {
+		return filepath.Base(filePath)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// GetIgnoreRules 获取当前的忽略规则
+func (a *App) GetIgnoreRules() IgnoreRulesConfig {
+	return a.getIgnoreRules()
+}
+
+// SetIgnoreRules 保存忽略规则，规则无效时返回错误
+// 已导入的漫画需要重建页面索引才会应用新规则
+func (a *App) SetIgnoreRules(config IgnoreRulesConfig) error {
+	if config.Libraries == nil {
+		config.Libraries = map[string][]string{}
+	}
+
+	var libraryPatterns [][]string
+	for _, patterns := range config.Libraries {
+		libraryPatterns = append(libraryPatterns, patterns)
+	}
+	if _, err := newIgnoreMatcher(config, libraryPatterns...); err != nil {
+		return err
+	}
+
+	if err := a.saveSetting(ignoreRulesSettingKey, config); err != nil {
+		return err
+	}
+
+	a.ignoreMu.Lock()
+	a.ignoreRules = &config
+	a.ignoreMu.Unlock()
+
+	return nil
+}
+
+// ResetIgnoreRules 恢复默认的忽略规则
+func (a *App) ResetIgnoreRules() error {
+	return a.SetIgnoreRules(defaultIgnoreRulesConfig())
+}
+
+// PreviewIgnoreRules 检查漫画路径中的某个条目会被哪类规则排除，用于调整规则时预览
+func (a *App) PreviewIgnoreRules(comicPath string, entryName string) map[string]interface{} {
+	m := a.ignoreMatcherFor(comicPath)
+	return map[string]interface{}{
+		"ignored":    m.ignored(entryName),
+		"creditPage": m.isCreditPage(entryName),
+	}
+}
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 磁盘缓存默认大小上限
+const defaultPageCacheBytes int64 = 1 << 30
+
+// imageWorkerPool 限制同时进行的图片解码/编码数量，避免大图同时解码占满内存
+type imageWorkerPool struct {
+	sem chan struct{}
+}
+
+// newImageWorkerPool 创建最多同时执行size个任务的工作池
+func newImageWorkerPool(size int) *imageWorkerPool {
+	if size < 1 {
+		size = 1
+	}
+	return &imageWorkerPool{sem: make(chan struct{}, size)}
+}
+
+// Do 在工作池中执行任务，池满时等待
+func (p *imageWorkerPool) Do(task func() error) error {
+	if p == nil {
+		return task()
+	}
+	p.sem <- struct{}{}
+	defer func() { <-p.sem }()
+	return task()
+}
+
+// pageCache 处理后图片的磁盘缓存，超过上限时删除最久未使用的文件
+type pageCache struct {
+	mu       sync.Mutex
+	dir      string
+	maxBytes int64
+	size     int64
+}
+
+// newPageCache 在用户缓存目录下创建页面缓存
+func newPageCache(maxBytes int64) *pageCache {
+	return newDiskCache("pages", maxBytes)
+}
+
+// newDiskCache 在用户缓存目录下创建名为name的缓存目录
+func newDiskCache(name string, maxBytes int64) *pageCache {
+	baseDir, err := os.UserCacheDir()
+	if err != nil {
+		baseDir = "cache"
+	}
+	dir := filepath.Join(baseDir, "r-comic", name)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		fmt.Printf("创建缓存目录失败 %s: %v\n", dir, err)
+		return nil
+	}
+
+	cache := &pageCache{dir: dir, maxBytes: maxBytes}
+	entries, _ := os.ReadDir(dir)
+	for _, entry := range entries {
+		if info, err := entry.Info(); err == nil && !entry.IsDir() {
+			cache.size += info.Size()
+		}
+	}
+
+	return cache
+}
+
+// pageCacheKey 由图片来源标识和处理参数生成缓存键
+func pageCacheKey(parts ...string) string {
+	sum := sha1.Sum([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
+
+// cacheExtensions 缓存文件扩展名与MIME类型的对应关系
+var cacheExtensions = map[string]string{
+	".jpg":  "image/jpeg",
+	".png":  "image/png",
+	".webp": "image/webp",
+}
+
+// Get 读取缓存，返回数据和MIME类型
+func (c *pageCache) Get(key string) ([]byte, string, bool) {
+	if c == nil {
+		return nil, "", false
+	}
+
+	for ext, contentType := range cacheExtensions {
+		path := filepath.Join(c.dir, key+ext)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		// 更新修改时间，淘汰时按最近使用排序
+		now := time.Now()
+		os.Chtimes(path, now, now)
+		return data, contentType, true
+	}
+
+	return nil, "", false
+}
+
+// Put 写入缓存，写入后超过上限时淘汰旧文件
+func (c *pageCache) Put(key string, data []byte, contentType string) {
+	if c == nil {
+		return
+	}
+
+	// 扩展名决定读取时返回的MIME类型，不认识的类型不缓存，避免以错误的类型返回
+	ext := ""
+	for candidate, candidateType := range cacheExtensions {
+		if candidateType == contentType {
+			ext = candidate
+			break
+		}
+	}
+	if ext == "" {
+		return
+	}
+
+	path := filepath.Join(c.dir, key+ext)
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		fmt.Printf("写入缓存失败 %s: %v\n", path, err)
+		return
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		fmt.Printf("写入缓存失败 %s: %v\n", path, err)
+		return
+	}
+
+	c.mu.Lock()
+	c.size += int64(len(data))
+	overLimit := c.size > c.maxBytes
+	c.mu.Unlock()
+
+	if overLimit {
+		c.evict()
+	}
+}
+
+// cachedFile 获取已缓存文件的路径，不存在时返回false
+func (c *pageCache) cachedFile(key, ext string) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+
+	path := filepath.Join(c.dir, key+ext)
+	if _, err := os.Stat(path); err != nil {
+		return "", false
+	}
+	now := time.Now()
+	os.Chtimes(path, now, now)
+	return path, true
+}
+
+// storeFile 将数据流写入缓存文件并返回路径，写入后超过上限时淘汰旧文件
+func (c *pageCache) storeFile(key, ext string, r io.Reader) (string, error) {
+	if c == nil {
+		return "", fmt.Errorf("缓存未初始化")
+	}
+
+	path := filepath.Join(c.dir, key+ext)
+	tmp, err := os.CreateTemp(c.dir, key+"-*.tmp")
+	if err != nil {
+		return "", fmt.Errorf("创建缓存文件失败: %v", err)
+	}
+	size, err := io.Copy(tmp, r)
+	tmp.Close()
+	if err != nil {
+		os.Remove(tmp.Name())
+		return "", fmt.Errorf("写入缓存失败: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return "", fmt.Errorf("写入缓存失败: %v", err)
+	}
+
+	c.mu.Lock()
+	c.size += size
+	overLimit := c.size > c.maxBytes
+	c.mu.Unlock()
+
+	if overLimit {
+		c.evict()
+	}
+
+	return path, nil
+}
+
+// evict 按修改时间从旧到新删除缓存文件，直到降到上限的80%
+func (c *pageCache) evict() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return
+	}
+
+	type cacheFile struct {
+		path    string
+		size    int64
+		modTime int64
+	}
+	var files []cacheFile
+	var total int64
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || entry.IsDir() {
+			continue
+		}
+		files = append(files, cacheFile{filepath.Join(c.dir, entry.Name()), info.Size(), info.ModTime().UnixNano()})
+		total += info.Size()
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].modTime < files[j].modTime })
+
+	target := c.maxBytes * 8 / 10
+	for _, file := range files {
+		if total <= target {
+			break
+		}
+		if os.Remove(file.path) == nil {
+			total -= file.size
+		}
+	}
+	c.size = total
+}
+
+// Clear 清空缓存目录
+func (c *pageCache) Clear() error {
+	if c == nil {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return fmt.Errorf("读取缓存目录失败: %v", err)
+	}
+	for _, entry := range entries {
+		os.Remove(filepath.Join(c.dir, entry.Name()))
+	}
+	c.size = 0
+
+	return nil
+}
+
+// ClearImageCache 清空处理后图片的磁盘缓存
+func (a *App) ClearImageCache() error {
+	return a.pageCache.Clear()
+}
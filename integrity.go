@@ -0,0 +1,406 @@
+package main
+
+import (
+	"archive/zip"
+	"bufio"
+	"context"
+	"fmt"
+	"image"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	integrityOK      = "ok"      // 所有条目校验通过
+	integrityBroken  = "broken"  // 存在损坏的条目或页面
+	integrityMissing = "missing" // 文件不存在或无法打开
+)
+
+// integrityProblem 校验时发现的问题，entry为压缩包中的条目名或文件夹中的相对路径
+type integrityProblem struct {
+	Entry  string `json:"entry"`
+	Reason string `json:"reason"`
+}
+
+// createIntegrityTables 创建完整性校验结果表并为漫画表添加校验状态字段
+func (a *App) createIntegrityTables() error {
+	createProblemTable := `
+	CREATE TABLE IF NOT EXISTS integrity_problems (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		comic_id INTEGER NOT NULL,
+		entry TEXT NOT NULL,
+		reason TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (comic_id) REFERENCES comics (id)
+	);`
+
+	_, err := a.db.Exec(createProblemTable)
+	if err != nil {
+		return fmt.Errorf("创建integrity_problems表失败: %v", err)
+	}
+
+	_, err = a.db.Exec(`CREATE INDEX IF NOT EXISTS idx_integrity_problems_comic ON integrity_problems (comic_id)`)
+	if err != nil {
+		return fmt.Errorf("创建integrity_problems索引失败: %v", err)
+	}
+
+	if err := a.ensureColumn("comics", "integrity_status", "TEXT DEFAULT ''"); err != nil {
+		return err
+	}
+	return a.ensureColumn("comics", "integrity_checked_at", "DATETIME")
+}
+
+// verifyImageEntry 完整读取一个条目：图片先解析头部，再读完剩余数据，zip条目读到末尾时会校验CRC32
+func verifyImageEntry(name string, r io.Reader, checkImage bool) error {
+	reader := bufio.NewReader(r)
+	header, _ := reader.Peek(sniffHeaderSize)
+	if checkImage && (imageExtensions[strings.ToLower(path.Ext(name))] || sniffImageType(header) != "") {
+		if _, _, err := image.DecodeConfig(reader); err != nil {
+			return fmt.Errorf("无法解析图片头部: %v", err)
+		}
+	}
+
+	if _, err := io.Copy(io.Discard, reader); err != nil {
+		if err == zip.ErrChecksum {
+			return fmt.Errorf("CRC32校验失败")
+		}
+		return fmt.Errorf("读取数据失败: %v", err)
+	}
+	return nil
+}
+
+// verifyArchive 校验压缩包中的所有条目，内层压缩包逐层展开校验
+func (a *App) verifyArchive(chain *archiveChain, prefix string, depth int, matcher *ignoreMatcher) []integrityProblem {
+	var problems []integrityProblem
+	for _, file := range chain.reader.File {
+		if file.FileInfo().IsDir() {
+			continue
+		}
+		entry := prefix + file.Name
+
+		rc, err := file.Open()
+		if err != nil {
+			problems = append(problems, integrityProblem{Entry: entry, Reason: fmt.Sprintf("打开条目失败: %v", err)})
+			continue
+		}
+		err = verifyImageEntry(file.Name, rc, !matcher.ignored(file.Name))
+		rc.Close()
+		if err != nil {
+			problems = append(problems, integrityProblem{Entry: entry, Reason: err.Error()})
+			continue
+		}
+
+		if !isArchiveEntry(file.Name) || depth >= maxNestedArchiveDepth {
+			continue
+		}
+		child := &archiveChain{reader: chain.reader, readerAt: chain.readerAt, key: chain.key}
+		if err := a.enterInnerArchive(child, file); err != nil {
+			problems = append(problems, integrityProblem{Entry: entry, Reason: err.Error()})
+			child.Close()
+			continue
+		}
+		problems = append(problems, a.verifyArchive(child, entry+archivePathSeparator, depth+1, matcher)...)
+		child.Close()
+	}
+
+	return problems
+}
+
+// verifyFolder 校验文件夹中的所有图片能否完整读取并解析头部
+func (a *App) verifyFolder(folderPath string) ([]integrityProblem, error) {
+	imageFiles, err := a.bfsSearchImagesFromFolder(folderPath, a.ignoreMatcherFor(folderPath))
+	if err != nil {
+		return nil, fmt.Errorf("搜索文件夹失败: %v", err)
+	}
+
+	var problems []integrityProblem
+	for _, filePath := range imageFiles {
+		entry := relativeEntryPath(folderPath, filePath)
+		file, err := os.Open(filePath)
+		if err != nil {
+			problems = append(problems, integrityProblem{Entry: entry, Reason: fmt.Sprintf("打开文件失败: %v", err)})
+			continue
+		}
+		err = verifyImageEntry(filePath, file, true)
+		file.Close()
+		if err != nil {
+			problems = append(problems, integrityProblem{Entry: entry, Reason: err.Error()})
+		}
+	}
+
+	return problems, nil
+}
+
+// verifyPath 校验漫画文件，返回校验状态和发现的问题
+func (a *App) verifyPath(filePath, fileType string) (string, []integrityProblem) {
+	if _, err := os.Stat(filePath); err != nil {
+		return integrityMissing, []integrityProblem{{Reason: fmt.Sprintf("文件不存在: %v", err)}}
+	}
+
+	var problems []integrityProblem
+	switch fileType {
+	case "zip":
+		chain, err := a.openArchiveChain(filePath, nil)
+		if err != nil {
+			return integrityMissing, []integrityProblem{{Reason: err.Error()}}
+		}
+		problems = a.verifyArchive(chain, "", 0, a.ignoreMatcherFor(filePath))
+		chain.Close()
+	case "folder":
+		var err error
+		problems, err = a.verifyFolder(filePath)
+		if err != nil {
+			return integrityMissing, []integrityProblem{{Reason: err.Error()}}
+		}
+	default:
+		return integrityMissing, []integrityProblem{{Reason: fmt.Sprintf("不支持的漫画类型: %s", fileType)}}
+	}
+
+	if len(problems) > 0 {
+		return integrityBroken, problems
+	}
+	return integrityOK, nil
+}
+
+// verifyComic 校验漫画并把结果写入数据库
+func (a *App) verifyComic(comicID int64) (string, []integrityProblem, error) {
+	filePath, fileType, err := a.getComicPath(comicID)
+	if err != nil {
+		return "", nil, err
+	}
+
+	status, problems := a.verifyPath(filePath, fileType)
+
+	tx, err := a.db.Begin()
+	if err != nil {
+		return "", nil, fmt.Errorf("开启事务失败: %v", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM integrity_problems WHERE comic_id = ?`, comicID); err != nil {
+		return "", nil, fmt.Errorf("清除校验结果失败: %v", err)
+	}
+	for _, problem := range problems {
+		_, err := tx.Exec(`INSERT INTO integrity_problems (comic_id, entry, reason) VALUES (?, ?, ?)`, comicID, problem.Entry, problem.Reason)
+		if err != nil {
+			return "", nil, fmt.Errorf("保存校验结果失败: %v", err)
+		}
+	}
+	_, err = tx.Exec(`UPDATE comics SET integrity_status = ?, integrity_checked_at = ? WHERE id = ?`, status, time.Now(), comicID)
+	if err != nil {
+		return "", nil, fmt.Errorf("更新校验状态失败: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", nil, fmt.Errorf("提交事务失败: %v", err)
+	}
+
+	return status, problems, nil
+}
+
+// integrityReport 生成返回给前端的校验结果
+func integrityReport(comicID int64, title, filePath, status string, problems []integrityProblem) map[string]interface{} {
+	if problems == nil {
+		problems = []integrityProblem{}
+	}
+	return map[string]interface{}{
+		"comicId":  comicID,
+		"title":    title,
+		"filePath": filePath,
+		"status":   status,
+		"problems": problems,
+	}
+}
+
+// VerifyComic 校验单本漫画：读取每个条目并校验CRC32，解析每页图片头部，结果记录到数据库
+func (a *App) VerifyComic(comicID int64) (map[string]interface{}, error) {
+	if a.db == nil {
+		return nil, fmt.Errorf("数据库未初始化")
+	}
+
+	status, problems, err := a.verifyComic(comicID)
+	if err != nil {
+		return nil, err
+	}
+
+	var title, filePath string
+	a.db.QueryRow(`SELECT title, file_path FROM comics WHERE id = ?`, comicID).Scan(&title, &filePath)
+	return integrityReport(comicID, title, filePath, status, problems), nil
+}
+
+// libraryComic 书库校验时需要的漫画信息
+type libraryComic struct {
+	id       int64
+	title    string
+	filePath string
+}
+
+// libraryComics 查询书库中的所有漫画
+func (a *App) libraryComics() ([]libraryComic, error) {
+	rows, err := a.db.Query(`SELECT id, title, file_path FROM comics ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("查询漫画失败: %v", err)
+	}
+	defer rows.Close()
+
+	var comics []libraryComic
+	for rows.Next() {
+		var comic libraryComic
+		if err := rows.Scan(&comic.id, &comic.title, &comic.filePath); err == nil {
+			comics = append(comics, comic)
+		}
+	}
+	return comics, nil
+}
+
+// verifyLibraryComic 校验一本漫画并记录结果，有问题时返回报告，没有问题或校验失败时返回nil
+func (a *App) verifyLibraryComic(comic libraryComic) map[string]interface{} {
+	status, problems, err := a.verifyComic(comic.id)
+	if err != nil {
+		fmt.Printf("校验漫画失败 %s: %v\n", comic.filePath, err)
+		return nil
+	}
+	if status == integrityOK {
+		return nil
+	}
+
+	fmt.Printf("漫画已损坏 %s: %d 处问题\n", comic.filePath, len(problems))
+	return integrityReport(comic.id, comic.title, comic.filePath, status, problems)
+}
+
+// StartVerifyLibrary 在后台校验书库中的所有漫画，返回任务ID，任务结果为有问题的漫画
+func (a *App) StartVerifyLibrary() (string, error) {
+	if a.db == nil {
+		return "", fmt.Errorf("数据库未初始化")
+	}
+
+	comics, err := a.libraryComics()
+	if err != nil {
+		return "", err
+	}
+
+	return a.startJob("verify", len(comics), func(ctx context.Context, job *backgroundJob) error {
+		for _, comic := range comics {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			// 没有问题的漫画不记录结果，避免结果列表过长
+			var result interface{}
+			if report := a.verifyLibraryComic(comic); report != nil {
+				result = report
+			}
+			job.advance(fmt.Sprintf("已校验 %s", comic.title), result)
+		}
+		return nil
+	}), nil
+}
+
+// GetBrokenComics 获取上次校验中有问题的漫画及其问题列表
+func (a *App) GetBrokenComics() ([]map[string]interface{}, error) {
+	if a.db == nil {
+		return nil, fmt.Errorf("数据库未初始化")
+	}
+
+	rows, err := a.db.Query(`SELECT id, title, file_path, integrity_status FROM comics
+		WHERE integrity_status IN (?, ?) ORDER BY title COLLATE NATURAL_ORDER`, integrityBroken, integrityMissing)
+	if err != nil {
+		return nil, fmt.Errorf("查询损坏漫画失败: %v", err)
+	}
+	var reports []map[string]interface{}
+	for rows.Next() {
+		var id int64
+		var title, filePath, status string
+		if err := rows.Scan(&id, &title, &filePath, &status); err == nil {
+			reports = append(reports, integrityReport(id, title, filePath, status, nil))
+		}
+	}
+	rows.Close()
+
+	for _, report := range reports {
+		problemRows, err := a.db.Query(`SELECT entry, reason FROM integrity_problems WHERE comic_id = ? ORDER BY id`, report["comicId"])
+		if err != nil {
+			return nil, fmt.Errorf("查询校验结果失败: %v", err)
+		}
+		var problems []integrityProblem
+		for problemRows.Next() {
+			var problem integrityProblem
+			if err := problemRows.Scan(&problem.Entry, &problem.Reason); err == nil {
+				problems = append(problems, problem)
+			}
+		}
+		problemRows.Close()
+		if problems != nil {
+			report["problems"] = problems
+		}
+	}
+
+	return reports, nil
+}
+
+// runVerifyCommand 命令行校验：r-comic verify [漫画路径...]
+// 不带路径时校验整个书库并记录结果，带路径时只校验指定的文件或文件夹；发现问题时返回1
+func runVerifyCommand(args []string) int {
+	app := NewApp()
+	app.startup(context.Background())
+	defer app.shutdown(context.Background())
+
+	if len(args) == 0 {
+		if app.db == nil {
+			fmt.Printf("校验失败: 数据库未初始化\n")
+			return 1
+		}
+		comics, err := app.libraryComics()
+		if err != nil {
+			fmt.Printf("校验失败: %v\n", err)
+			return 1
+		}
+
+		broken := 0
+		for _, comic := range comics {
+			if report := app.verifyLibraryComic(comic); report != nil {
+				printIntegrityReport(comic.filePath, report["status"].(string), report["problems"].([]integrityProblem))
+				broken++
+			}
+		}
+		fmt.Printf("共校验 %d 本漫画，%d 本有问题\n", len(comics), broken)
+		if broken > 0 {
+			return 1
+		}
+		return 0
+	}
+
+	exitCode := 0
+	for _, arg := range args {
+		filePath, err := filepath.Abs(arg)
+		if err != nil {
+			filePath = arg
+		}
+		fileType := "zip"
+		if info, err := os.Stat(filePath); err == nil && info.IsDir() {
+			fileType = "folder"
+		}
+		status, problems := app.verifyPath(filePath, fileType)
+		printIntegrityReport(filePath, status, problems)
+		if status != integrityOK {
+			exitCode = 1
+		}
+	}
+	return exitCode
+}
+
+// printIntegrityReport 在命令行输出校验结果
+func printIntegrityReport(filePath, status string, problems []integrityProblem) {
+	fmt.Printf("[%s] %s\n", status, filePath)
+	for _, problem := range problems {
+		if problem.Entry == "" {
+			fmt.Printf("    %s\n", problem.Reason)
+		} else {
+			fmt.Printf("    %s: %s\n", problem.Entry, problem.Reason)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	jobRunning   = "running"
+	jobDone      = "done"
+	jobFailed    = "failed"
+	jobCancelled = "cancelled"
+)
+
+// backgroundJob 后台任务的状态，前端通过 GetJob/ListJobs 轮询进度
+type backgroundJob struct {
+	mu         sync.Mutex
+	id         string
+	kind       string
+	status     string
+	total      int
+	done       int
+	message    string
+	results    []interface{}
+	err        string
+	startedAt  time.Time
+	finishedAt time.Time
+	cancel     context.CancelFunc
+}
+
+// advance 完成一项工作并记录结果
+func (j *backgroundJob) advance(message string, result interface{}) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.done++
+	j.message = message
+	if result != nil {
+		j.results = append(j.results, result)
+	}
+}
+
+// addResult 记录结果但不计入进度，用于任务最后汇总的结果
+func (j *backgroundJob) addResult(result interface{}) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.results = append(j.results, result)
+}
+
+// snapshot 生成返回给前端的任务状态
+func (j *backgroundJob) snapshot() map[string]interface{} {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	results := append([]interface{}{}, j.results...)
+	snapshot := map[string]interface{}{
+		"id":        j.id,
+		"kind":      j.kind,
+		"status":    j.status,
+		"total":     j.total,
+		"done":      j.done,
+		"message":   j.message,
+		"results":   results,
+		"error":     j.err,
+		"startedAt": j.startedAt,
+	}
+	if !j.finishedAt.IsZero() {
+		snapshot["finishedAt"] = j.finishedAt
+	}
+	return snapshot
+}
+
+// jobManager 管理正在运行和已结束的后台任务
+type jobManager struct {
+	mu     sync.Mutex
+	jobs   map[string]*backgroundJob
+	nextID int64
+	busy   map[int64]bool // 正在被改写文件的漫画
+}
+
+// startJob 在后台运行任务，返回任务ID；run应在ctx取消时尽快返回
+func (a *App) startJob(kind string, total int, run func(ctx context.Context, job *backgroundJob) error) string {
+	a.jobs.mu.Lock()
+	if a.jobs.jobs == nil {
+		a.jobs.jobs = make(map[string]*backgroundJob)
+	}
+	a.jobs.nextID++
+	ctx, cancel := context.WithCancel(context.Background())
+	job := &backgroundJob{
+		id:        fmt.Sprintf("%s-%d", kind, a.jobs.nextID),
+		kind:      kind,
+		status:    jobRunning,
+		total:     total,
+		startedAt: time.Now(),
+		cancel:    cancel,
+	}
+	a.jobs.jobs[job.id] = job
+	a.jobs.mu.Unlock()
+
+	go func() {
+		defer cancel()
+		err := run(ctx, job)
+
+		job.mu.Lock()
+		defer job.mu.Unlock()
+		job.finishedAt = time.Now()
+		switch {
+		case ctx.Err() != nil:
+			job.status = jobCancelled
+		case err != nil:
+			job.status = jobFailed
+			job.err = err.Error()
+		default:
+			job.status = jobDone
+		}
+		fmt.Printf("后台任务 %s 结束: %s\n", job.id, job.status)
+	}()
+
+	return job.id
+}
+
+// lockComic 标记漫画的文件正在被改写（重新压缩、转换、写回元数据），同一本漫画同时只允许一个改写操作
+// 返回的函数用于解除标记
+func (a *App) lockComic(comicID int64) (func(), error) {
+	a.jobs.mu.Lock()
+	defer a.jobs.mu.Unlock()
+
+	if a.jobs.busy == nil {
+		a.jobs.busy = make(map[int64]bool)
+	}
+	if a.jobs.busy[comicID] {
+		return nil, fmt.Errorf("漫画正在被其他任务处理: %d", comicID)
+	}
+	a.jobs.busy[comicID] = true
+
+	return func() {
+		a.jobs.mu.Lock()
+		defer a.jobs.mu.Unlock()
+		delete(a.jobs.busy, comicID)
+	}, nil
+}
+
+// getJob 按ID查找任务
+func (a *App) getJob(jobID string) (*backgroundJob, error) {
+	a.jobs.mu.Lock()
+	defer a.jobs.mu.Unlock()
+
+	job, ok := a.jobs.jobs[jobID]
+	if !ok {
+		return nil, fmt.Errorf("任务不存在: %s", jobID)
+	}
+	return job, nil
+}
+
+// GetJob 获取后台任务的进度和结果
+func (a *App) GetJob(jobID string) (map[string]interface{}, error) {
+	job, err := a.getJob(jobID)
+	if err != nil {
+		return nil, err
+	}
+	return job.snapshot(), nil
+}
+
+// ListJobs 列出所有后台任务，最近开始的在前
+func (a *App) ListJobs() []map[string]interface{} {
+	a.jobs.mu.Lock()
+	jobs := make([]*backgroundJob, 0, len(a.jobs.jobs))
+	for _, job := range a.jobs.jobs {
+		jobs = append(jobs, job)
+	}
+	a.jobs.mu.Unlock()
+
+	snapshots := make([]map[string]interface{}, 0, len(jobs))
+	for _, job := range jobs {
+		snapshots = append(snapshots, job.snapshot())
+	}
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i]["startedAt"].(time.Time).After(snapshots[j]["startedAt"].(time.Time))
+	})
+	return snapshots
+}
+
+// CancelJob 取消正在运行的后台任务
+func (a *App) CancelJob(jobID string) error {
+	job, err := a.getJob(jobID)
+	if err != nil {
+		return err
+	}
+	job.cancel()
+	return nil
+}
+
+// ClearFinishedJobs 移除已结束的任务记录
+func (a *App) ClearFinishedJobs() {
+	a.jobs.mu.Lock()
+	defer a.jobs.mu.Unlock()
+
+	for id, job := range a.jobs.jobs {
+		job.mu.Lock()
+		finished := job.status != jobRunning
+		job.mu.Unlock()
+		if finished {
+			delete(a.jobs.jobs, id)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// comicSelectColumns 查询漫画列表时统一使用的字段，查询中漫画表别名须为c
+const comicSelectColumns = `c.id, c.title, c.file_path, c.file_type, COALESCE(c.first_image, ''), COALESCE(c.file_size, 0),
+	COALESCE(c.series_volume, ''), COALESCE(c.series_number, ''), c.last_page, c.page_count, c.is_read,
+	COALESCE(c.rating, 0), COALESCE(c.is_favorite, 0), c.created_at, c.updated_at, COALESCE(c.thumbnail, '')`
+
+// LibraryQuery 漫画库查询条件
+type LibraryQuery struct {
+	Keyword       string  `json:"keyword"`
+	FavoritesOnly bool    `json:"favoritesOnly"`
+	UnreadOnly    bool    `json:"unreadOnly"`
+	MinRating     float64 `json:"minRating"`
+	HasNotes      bool    `json:"hasNotes"`
+	Tag           string  `json:"tag"`
+	SortBy        string  `json:"sortBy"`
+	SortDesc      bool    `json:"sortDesc"`
+}
+
+// librarySortColumns 允许排序的字段，避免拼接任意SQL
+var librarySortColumns = map[string]string{
+	"title":     "c.title COLLATE NATURAL_ORDER",
+	"createdAt": "c.created_at",
+	"updatedAt": "c.updated_at",
+	"lastRead":  "c.last_read_at",
+	"fileSize":  "c.file_size",
+	"rating":    "c.rating",
+	"favorite":  "c.is_favorite",
+}
+
+// queryComics 执行以comicSelectColumns为字段的查询并转换为前端使用的map
+func (a *App) queryComics(query string, args ...interface{}) ([]map[string]interface{}, error) {
+	if a.db == nil {
+		return nil, fmt.Errorf("数据库未初始化")
+	}
+
+	rows, err := a.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("查询漫画信息失败: %v", err)
+	}
+	defer rows.Close()
+
+	var comics []map[string]interface{}
+	for rows.Next() {
+		var id, fileSize, lastPage, pageCount int64
+		var title, filePath, fileType, firstImage, volume, number, createdAt, updatedAt, thumbnail string
+		var isRead, isFavorite bool
+		var rating float64
+
+		err := rows.Scan(&id, &title, &filePath, &fileType, &firstImage, &fileSize, &volume, &number, &lastPage, &pageCount, &isRead,
+			&rating, &isFavorite, &createdAt, &updatedAt, &thumbnail)
+		if err != nil {
+			continue
+		}
+
+		comics = append(comics, map[string]interface{}{
+			"id":         id,
+			"title":      title,
+			"filePath":   filePath,
+			"fileType":   fileType,
+			"firstImage": firstImage,
+			"fileSize":   fileSize,
+			"volume":     volume,
+			"number":     number,
+			"lastPage":   lastPage,
+			"pageCount":  pageCount,
+			"isRead":     isRead,
+			"rating":     rating,
+			"isFavorite": isFavorite,
+			"createdAt":  createdAt,
+			"updatedAt":  updatedAt,
+			"thumbnail":  thumbnail,
+		})
+	}
+
+	return comics, nil
+}
+
+// QueryComics 按条件筛选并排序漫画库
+func (a *App) QueryComics(q LibraryQuery) ([]map[string]interface{}, error) {
+	var conditions []string
+	var args []interface{}
+
+	if q.Keyword != "" {
+		searchPattern := "%" + q.Keyword + "%"
+		conditions = append(conditions, "(c.title LIKE ? OR c.file_path LIKE ?)")
+		args = append(args, searchPattern, searchPattern)
+	}
+	if q.FavoritesOnly {
+		conditions = append(conditions, "c.is_favorite = 1")
+	}
+	if q.UnreadOnly {
+		conditions = append(conditions, "c.is_read = 0")
+	}
+	if q.MinRating > 0 {
+		conditions = append(conditions, "c.rating >= ?")
+		args = append(args, q.MinRating)
+	}
+	if q.HasNotes {
+		conditions = append(conditions, "COALESCE(c.notes, '') <> ''")
+	}
+	if q.Tag != "" {
+		conditions = append(conditions, "EXISTS (SELECT 1 FROM comic_tags ct JOIN tags t ON t.id = ct.tag_id WHERE ct.comic_id = c.id AND t.name = ?)")
+		args = append(args, strings.TrimSpace(q.Tag))
+	}
+
+	sortColumn, ok := librarySortColumns[q.SortBy]
+	if !ok {
+		sortColumn = librarySortColumns["updatedAt"]
+		if q.SortBy == "" {
+			// 默认最近更新的排在前面
+			q.SortDesc = true
+		}
+	}
+	direction := "ASC"
+	if q.SortDesc {
+		direction = "DESC"
+	}
+
+	query := `SELECT ` + comicSelectColumns + ` FROM comics c`
+	if len(conditions) > 0 {
+		query += ` WHERE ` + strings.Join(conditions, " AND ")
+	}
+	query += fmt.Sprintf(` ORDER BY %s %s, c.id %s`, sortColumn, direction, direction)
+
+	return a.queryComics(query, args...)
+}
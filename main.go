@@ -2,6 +2,7 @@ package main
 
 import (
 	"archive/zip"
+	"bufio"
 	"embed"
 	"fmt"
 	"io"
@@ -21,10 +22,11 @@ var assets embed.FS
 
 type FileLoader struct {
 	http.Handler
+	app *App
 }
 
-func NewFileLoader() *FileLoader {
-	return &FileLoader{}
+func NewFileLoader(app *App) *FileLoader {
+	return &FileLoader{app: app}
 }
 
 func (h *FileLoader) ServeHTTP(res http.ResponseWriter, req *http.Request) {
@@ -50,8 +52,33 @@ func (h *FileLoader) ServeHTTP(res http.ResponseWriter, req *http.Request) {
 	println("Requesting file:", requestedFilename)
 	println("Contains '!':", strings.Contains(requestedFilename, "!"))
 
+	// 按漫画ID和页码访问页面：/comic/<id>/page/<n>
+	if strings.HasPrefix(requestedFilename, comicPageRoutePrefix) {
+		println("=== Processing Comic Page Request ===")
+		h.handleComicPage(res, req)
+		return
+	}
+
+	// 带缩放、转码等参数的图片请求
+	if transform, err := parseImageTransform(req.URL.Query()); err != nil {
+		res.WriteHeader(http.StatusBadRequest)
+		res.Write([]byte(err.Error()))
+		return
+	} else if !transform.isIdentity() {
+		println("=== Processing Transformed Image Request ===")
+		h.handleTransformedFile(res, req, transform)
+		return
+	}
+
+	// webview无法显示的新格式图片转码后返回
+	if format, ok := modernImageExtensions[strings.ToLower(filepath.Ext(requestedFilename))]; ok && !webviewDisplayable[format] {
+		println("=== Processing Transcoded Image Request ===")
+		h.handleTransformedFile(res, req, imageTransform{})
+		return
+	}
+
 	// 检查是否是ZIP文件中的图片请求
-	if strings.Contains(requestedFilename, "!") {
+	if isArchivePath(requestedFilename) {
 		println("=== Processing ZIP Image Request ===")
 		h.handleZipImage(res, requestedFilename)
 		println("=== ZIP Image Request Processing Complete ===")
@@ -69,6 +96,9 @@ func (h *FileLoader) ServeHTTP(res http.ResponseWriter, req *http.Request) {
 	}
 
 	println("Successfully read file, size:", len(fileData), "bytes")
+	if contentType := sniffImageType(fileData); contentType != "" {
+		res.Header().Set("Content-Type", contentType)
+	}
 	res.Write(fileData)
 	println("=== Regular File Request Complete ===")
 }
@@ -79,16 +109,13 @@ func (h *FileLoader) handleZipImage(res http.ResponseWriter, requestPath string)
 	println("Request path:", requestPath)
 
 	// 分割路径：zip文件路径!图片路径
-	parts := strings.SplitN(requestPath, "!", 2)
-	if len(parts) != 2 {
+	zipFilePath, imagePath, ok := cutArchivePath(requestPath)
+	if !ok {
 		res.WriteHeader(http.StatusBadRequest)
 		res.Write([]byte("Invalid format. Expected: zipfile!imagepath"))
 		return
 	}
 
-	zipFilePath := parts[0]
-	imagePath := parts[1]
-
 	// URL解码处理中文路径
 	decodedImagePath, err := url.QueryUnescape(imagePath)
 	if err != nil {
@@ -100,6 +127,12 @@ func (h *FileLoader) handleZipImage(res http.ResponseWriter, requestPath string)
 	println("Original image path in ZIP:", imagePath)
 	println("Decoded image path in ZIP:", decodedImagePath)
 
+	// 嵌套压缩包中的图片：外层.zip!内层.zip!图片
+	if isArchivePath(decodedImagePath) {
+		h.handleNestedZipImage(res, zipFilePath+"!"+decodedImagePath)
+		return
+	}
+
 	// 检查ZIP文件是否存在
 	if _, err := os.Stat(zipFilePath); err != nil {
 		res.WriteHeader(http.StatusNotFound)
@@ -158,32 +191,21 @@ func (h *FileLoader) handleZipImage(res http.ResponseWriter, requestPath string)
 	}
 	defer fileReader.Close()
 
-	// 设置正确的Content-Type
-	ext := strings.ToLower(filepath.Ext(imagePath))
-	var contentType string
-	switch ext {
-	case ".jpg", ".jpeg":
-		contentType = "image/jpeg"
-	case ".png":
-		contentType = "image/png"
-	case ".gif":
-		contentType = "image/gif"
-	case ".bmp":
-		contentType = "image/bmp"
-	case ".webp":
-		contentType = "image/webp"
-	case ".tiff", ".tif":
-		contentType = "image/tiff"
-	default:
-		contentType = "image/jpeg"
+	// 根据文件头设置Content-Type，扩展名错误或没有扩展名时也能正确显示
+	reader := bufio.NewReader(fileReader)
+	header, _ := reader.Peek(sniffHeaderSize)
+	if format := detectModernImageFormat(header); format != "" && !webviewDisplayable[format] {
+		h.serveTransformedImage(res, comicPage{Name: targetFile.Name, Path: zipFilePath + "!" + targetFile.Name}, imageTransform{}, nil)
+		return
 	}
+	contentType := pageContentType(header, targetFile.Name)
 
 	res.Header().Set("Content-Type", contentType)
 	res.Header().Set("Cache-Control", "public, max-age=3600") // 缓存1小时
 	res.Header().Set("Content-Length", fmt.Sprintf("%d", targetFile.UncompressedSize64))
 
 	// 以流的方式传输图片数据
-	written, err := io.Copy(res, fileReader)
+	written, err := io.Copy(res, reader)
 	if err != nil {
 		println("Error streaming image:", err.Error())
 		return
@@ -194,6 +216,11 @@ func (h *FileLoader) handleZipImage(res http.ResponseWriter, requestPath string)
 }
 
 func main() {
+	// 命令行校验书库完整性：r-comic verify [漫画路径...]
+	if len(os.Args) > 1 && os.Args[1] == "verify" {
+		os.Exit(runVerifyCommand(os.Args[2:]))
+	}
+
 	println("=== Starting R-Comic Application ===")
 	println("Initializing HTTP server with custom file loader...")
 
@@ -207,7 +234,7 @@ func main() {
 		Height: 768,
 		AssetServer: &assetserver.Options{
 			Assets:  assets,
-			Handler: NewFileLoader(),
+			Handler: NewFileLoader(app),
 		},
 		BackgroundColour: &options.RGBA{R: 27, G: 38, B: 54, A: 1},
 		OnStartup:        app.startup,
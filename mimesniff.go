@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"path"
+	"strings"
+)
+
+// sniffHeaderSize 识别图片类型时读取的文件头长度
+const sniffHeaderSize = 64
+
+// imageExtensions 按扩展名识别的图片文件
+var imageExtensions = map[string]bool{
+	".jpg": true, ".jpeg": true, ".png": true, ".gif": true,
+	".bmp": true, ".webp": true, ".tiff": true, ".tif": true,
+	".avif": true, ".jxl": true, ".heic": true, ".heif": true,
+}
+
+// modernImageMIMETypes 新格式图片对应的MIME类型
+var modernImageMIMETypes = map[string]string{
+	"avif": "image/avif",
+	"jxl":  "image/jxl",
+	"heif": "image/heif",
+}
+
+// sniffImageType 根据文件头识别图片的MIME类型，无法识别时返回空字符串
+func sniffImageType(header []byte) string {
+	switch {
+	case bytes.HasPrefix(header, []byte{0xff, 0xd8, 0xff}):
+		return "image/jpeg"
+	case bytes.HasPrefix(header, []byte("\x89PNG\r\n\x1a\n")):
+		return "image/png"
+	case bytes.HasPrefix(header, []byte("GIF87a")), bytes.HasPrefix(header, []byte("GIF89a")):
+		return "image/gif"
+	case len(header) >= 12 && string(header[0:4]) == "RIFF" && string(header[8:12]) == "WEBP":
+		return "image/webp"
+	case bytes.HasPrefix(header, []byte("II*\x00")), bytes.HasPrefix(header, []byte("MM\x00*")):
+		return "image/tiff"
+	case bytes.HasPrefix(header, []byte("BM")) && len(header) >= 14:
+		return "image/bmp"
+	}
+
+	return modernImageMIMETypes[detectModernImageFormat(header)]
+}
+
+// pageContentType 优先根据文件头确定MIME类型，识别不了时再看扩展名
+func pageContentType(header []byte, name string) string {
+	if contentType := sniffImageType(header); contentType != "" {
+		return contentType
+	}
+	return imageContentType(name)
+}
+
+// readHeader 读取数据流开头用于识别类型
+func readHeader(r io.Reader) []byte {
+	header := make([]byte, sniffHeaderSize)
+	n, _ := io.ReadFull(r, header)
+	return header[:n]
+}
+
+// isImageEntry 判断条目是否为漫画页面
+// 有图片扩展名的直接认定，没有扩展名或扩展名不认识（如 page.001、img.dat）的读取文件头识别
+func isImageEntry(name string, open func() (io.ReadCloser, error)) bool {
+	ext := strings.ToLower(path.Ext(strings.ReplaceAll(name, "\\", "/")))
+	if imageExtensions[ext] {
+		return true
+	}
+	if open == nil {
+		return false
+	}
+
+	rc, err := open()
+	if err != nil {
+		return false
+	}
+	defer rc.Close()
+
+	return sniffImageType(readHeader(rc)) != ""
+}
@@ -0,0 +1,341 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	_ "golang.org/x/image/webp"
+)
+
+// modernImageExtensions 新格式图片的扩展名
+var modernImageExtensions = map[string]string{
+	".avif": "avif",
+	".jxl":  "jxl",
+	".heic": "heif",
+	".heif": "heif",
+}
+
+// webviewDisplayable 新格式中webview可以直接显示的格式，其余需要转码
+var webviewDisplayable = map[string]bool{
+	"avif": true,
+}
+
+// externalDecoders 各格式可用的外部解码工具，按顺序尝试第一个存在的命令
+// {in} 和 {out} 分别替换为输入文件和输出的PNG文件
+var externalDecoders = map[string][][]string{
+	"jxl": {
+		{"djxl", "{in}", "{out}"},
+		{"magick", "{in}", "{out}"},
+		{"ffmpeg", "-loglevel", "error", "-y", "-i", "{in}", "{out}"},
+	},
+	"avif": {
+		{"avifdec", "{in}", "{out}"},
+		{"heif-dec", "{in}", "{out}"},
+		{"magick", "{in}", "{out}"},
+		{"ffmpeg", "-loglevel", "error", "-y", "-i", "{in}", "{out}"},
+	},
+	"heif": {
+		{"heif-dec", "{in}", "{out}"},
+		{"heif-convert", "{in}", "{out}"},
+		{"magick", "{in}", "{out}"},
+	},
+}
+
+// heifBrands ISOBMFF文件类型中表示HEIF图片的品牌
+var heifBrands = map[string]bool{
+	"heic": true, "heix": true, "hevc": true, "hevx": true,
+	"heim": true, "heis": true, "mif1": true, "msf1": true,
+}
+
+// jxlContainerMagic JPEG XL容器格式的文件头
+const jxlContainerMagic = "\x00\x00\x00\x0cJXL \x0d\x0a\x87\x0a"
+
+func init() {
+	image.RegisterFormat("jxl", "\xff\x0a", decodeJXL, decodeJXLConfig)
+	image.RegisterFormat("jxl", jxlContainerMagic, decodeJXL, decodeJXLConfig)
+	for _, brand := range []string{"avif", "avis"} {
+		image.RegisterFormat("avif", "????ftyp"+brand, decodeAVIF, decodeISOBMFFConfig)
+	}
+	for brand := range heifBrands {
+		image.RegisterFormat("heif", "????ftyp"+brand, decodeHEIF, decodeISOBMFFConfig)
+	}
+}
+
+// detectModernImageFormat 根据文件头识别AVIF、JPEG XL和HEIF，不是这些格式时返回空字符串
+func detectModernImageFormat(header []byte) string {
+	if bytes.HasPrefix(header, []byte{0xff, 0x0a}) || bytes.HasPrefix(header, []byte(jxlContainerMagic)) {
+		return "jxl"
+	}
+	if len(header) < 12 || string(header[4:8]) != "ftyp" {
+		return ""
+	}
+
+	// 主品牌优先，其次检查兼容品牌
+	brands := []string{string(header[8:12])}
+	if size := int(binary.BigEndian.Uint32(header[0:4])); size > 16 && size <= len(header) {
+		for i := 16; i+4 <= size; i += 4 {
+			brands = append(brands, string(header[i:i+4]))
+		}
+	}
+	for _, brand := range brands {
+		if brand == "avif" || brand == "avis" {
+			return "avif"
+		}
+	}
+	for _, brand := range brands {
+		if heifBrands[brand] {
+			return "heif"
+		}
+	}
+
+	return ""
+}
+
+// decodeWithExternalTool 将数据写入临时文件，调用外部工具转换为PNG后解码
+func decodeWithExternalTool(format string, r io.Reader) (image.Image, error) {
+	tmpDir, err := os.MkdirTemp("", "r-comic-decode-*")
+	if err != nil {
+		return nil, fmt.Errorf("创建临时目录失败: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	inPath := filepath.Join(tmpDir, "in."+format)
+	outPath := filepath.Join(tmpDir, "out.png")
+
+	in, err := os.Create(inPath)
+	if err != nil {
+		return nil, fmt.Errorf("创建临时文件失败: %v", err)
+	}
+	_, err = io.Copy(in, r)
+	in.Close()
+	if err != nil {
+		return nil, fmt.Errorf("写入临时文件失败: %v", err)
+	}
+
+	for _, command := range externalDecoders[format] {
+		binary, err := exec.LookPath(command[0])
+		if err != nil {
+			continue
+		}
+
+		args := make([]string, 0, len(command)-1)
+		for _, arg := range command[1:] {
+			arg = strings.ReplaceAll(arg, "{in}", inPath)
+			arg = strings.ReplaceAll(arg, "{out}", outPath)
+			args = append(args, arg)
+		}
+
+		output, err := exec.Command(binary, args...).CombinedOutput()
+		if err != nil {
+			fmt.Printf("%s 解码失败: %v %s\n", command[0], err, strings.TrimSpace(string(output)))
+			continue
+		}
+
+		out, err := os.Open(outPath)
+		if err != nil {
+			continue
+		}
+		img, err := png.Decode(out)
+		out.Close()
+		if err == nil {
+			return img, nil
+		}
+	}
+
+	return nil, fmt.Errorf("没有可用的%s解码工具，请安装: %s", format, decoderNames(format))
+}
+
+// decoderNames 列出格式支持的外部解码工具名称
+func decoderNames(format string) string {
+	var names []string
+	for _, command := range externalDecoders[format] {
+		names = append(names, command[0])
+	}
+	return strings.Join(names, " / ")
+}
+
+func decodeJXL(r io.Reader) (image.Image, error)  { return decodeWithExternalTool("jxl", r) }
+func decodeAVIF(r io.Reader) (image.Image, error) { return decodeWithExternalTool("avif", r) }
+func decodeHEIF(r io.Reader) (image.Image, error) { return decodeWithExternalTool("heif", r) }
+
+// isobmffBoxes 遍历ISOBMFF盒子，返回类型和内容
+func isobmffBoxes(data []byte, visit func(boxType string, payload []byte) bool) {
+	for len(data) >= 8 {
+		size := uint64(binary.BigEndian.Uint32(data[0:4]))
+		boxType := string(data[4:8])
+		header := uint64(8)
+		switch size {
+		case 0:
+			size = uint64(len(data))
+		case 1:
+			if len(data) < 16 {
+				return
+			}
+			size = binary.BigEndian.Uint64(data[8:16])
+			header = 16
+		}
+		if size < header || size > uint64(len(data)) {
+			return
+		}
+		if !visit(boxType, data[header:size]) {
+			return
+		}
+		data = data[size:]
+	}
+}
+
+// findISOBMFFBox 按路径查找嵌套盒子，meta为FullBox需要跳过4字节版本和标志
+func findISOBMFFBox(data []byte, path ...string) []byte {
+	if len(path) == 0 {
+		return data
+	}
+
+	var found []byte
+	isobmffBoxes(data, func(boxType string, payload []byte) bool {
+		if boxType != path[0] {
+			return true
+		}
+		if boxType == "meta" {
+			if len(payload) < 4 {
+				return false
+			}
+			payload = payload[4:]
+		}
+		found = findISOBMFFBox(payload, path[1:]...)
+		return found == nil
+	})
+
+	return found
+}
+
+// decodeISOBMFFConfig 从AVIF/HEIF的ispe属性中读取图片尺寸
+func decodeISOBMFFConfig(r io.Reader) (image.Config, error) {
+	data, err := io.ReadAll(io.LimitReader(r, 1<<20))
+	if err != nil {
+		return image.Config{}, err
+	}
+
+	ispe := findISOBMFFBox(data, "meta", "iprp", "ipco", "ispe")
+	if len(ispe) < 12 {
+		return image.Config{}, fmt.Errorf("找不到图片尺寸信息")
+	}
+
+	return image.Config{
+		ColorModel: color.RGBAModel,
+		Width:      int(binary.BigEndian.Uint32(ispe[4:8])),
+		Height:     int(binary.BigEndian.Uint32(ispe[8:12])),
+	}, nil
+}
+
+// jxlBitReader 按JPEG XL规范从低位开始读取比特
+type jxlBitReader struct {
+	data []byte
+	pos  int
+}
+
+func (b *jxlBitReader) read(n int) (uint32, error) {
+	var value uint32
+	for i := 0; i < n; i++ {
+		index := b.pos / 8
+		if index >= len(b.data) {
+			return 0, io.ErrUnexpectedEOF
+		}
+		value |= uint32((b.data[index]>>(b.pos%8))&1) << i
+		b.pos++
+	}
+	return value, nil
+}
+
+// readJXLDimension 读取SizeHeader中的一个尺寸
+func readJXLDimension(b *jxlBitReader, small bool) (uint32, error) {
+	if small {
+		v, err := b.read(5)
+		return (v + 1) * 8, err
+	}
+
+	selector, err := b.read(2)
+	if err != nil {
+		return 0, err
+	}
+	v, err := b.read([]int{9, 13, 18, 30}[selector])
+	return v + 1, err
+}
+
+// jxlRatios SizeHeader中宽高比编码对应的分子和分母
+var jxlRatios = [][2]uint64{{1, 1}, {12, 10}, {4, 3}, {3, 2}, {16, 9}, {5, 4}, {2, 1}}
+
+// jxlCodestream 从裸码流或容器格式中取出码流
+func jxlCodestream(data []byte) []byte {
+	if !bytes.HasPrefix(data, []byte(jxlContainerMagic)) {
+		return data
+	}
+
+	var codestream []byte
+	isobmffBoxes(data, func(boxType string, payload []byte) bool {
+		switch boxType {
+		case "jxlc":
+			codestream = payload
+			return false
+		case "jxlp":
+			if len(payload) > 4 {
+				codestream = payload[4:]
+			}
+			return false
+		}
+		return true
+	})
+
+	return codestream
+}
+
+// decodeJXLConfig 解析JPEG XL的SizeHeader获取图片尺寸
+func decodeJXLConfig(r io.Reader) (image.Config, error) {
+	data, err := io.ReadAll(io.LimitReader(r, 1<<16))
+	if err != nil {
+		return image.Config{}, err
+	}
+
+	codestream := jxlCodestream(data)
+	if len(codestream) < 3 || codestream[0] != 0xff || codestream[1] != 0x0a {
+		return image.Config{}, fmt.Errorf("无效的JPEG XL码流")
+	}
+
+	b := &jxlBitReader{data: codestream[2:]}
+	smallBit, err := b.read(1)
+	if err != nil {
+		return image.Config{}, err
+	}
+	small := smallBit == 1
+
+	height, err := readJXLDimension(b, small)
+	if err != nil {
+		return image.Config{}, err
+	}
+
+	ratio, err := b.read(3)
+	if err != nil {
+		return image.Config{}, err
+	}
+
+	var width uint32
+	if ratio == 0 {
+		width, err = readJXLDimension(b, small)
+		if err != nil {
+			return image.Config{}, err
+		}
+	} else {
+		r := jxlRatios[ratio-1]
+		width = uint32(uint64(height) * r[0] / r[1])
+	}
+
+	return image.Config{ColorModel: color.RGBAModel, Width: int(width), Height: int(height)}, nil
+}
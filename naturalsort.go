@@ -0,0 +1,359 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/mattn/go-sqlite3"
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+)
+
+// sortLocaleSettingKey 文本排序使用的语言在settings表中的键
+const sortLocaleSettingKey = "sort_locale"
+
+// defaultSortLocale 默认按中文排序，汉字按拼音顺序
+const defaultSortLocale = "zh"
+
+// naturalSQLiteDriver 注册了NATURAL_ORDER排序规则的SQLite驱动名
+const naturalSQLiteDriver = "sqlite3_natural"
+
+func init() {
+	sql.Register(naturalSQLiteDriver, &sqlite3.SQLiteDriver{
+		ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+			return conn.RegisterCollation("NATURAL_ORDER", naturalCompare)
+		},
+	})
+}
+
+// naturalCollator 文本部分的比较器，collate.Collator不是并发安全的，需要加锁
+var naturalCollator = struct {
+	sync.Mutex
+	locale   string
+	collator *collate.Collator
+}{
+	locale:   defaultSortLocale,
+	collator: newTextCollator(language.Chinese),
+}
+
+// newTextCollator 创建忽略大小写和全半角差异的比较器
+func newTextCollator(tag language.Tag) *collate.Collator {
+	return collate.New(tag, collate.IgnoreCase, collate.IgnoreWidth)
+}
+
+// setNaturalSortLocale 切换文本排序使用的语言
+func setNaturalSortLocale(locale string) error {
+	tag, err := language.Parse(locale)
+	if err != nil {
+		return fmt.Errorf("无效的语言: %s", locale)
+	}
+
+	naturalCollator.Lock()
+	naturalCollator.locale = tag.String()
+	naturalCollator.collator = newTextCollator(tag)
+	naturalCollator.Unlock()
+
+	return nil
+}
+
+// compareText 按当前语言比较文本，忽略大小写和全半角
+func compareText(x, y string) int {
+	naturalCollator.Lock()
+	defer naturalCollator.Unlock()
+	return naturalCollator.collator.CompareString(x, y)
+}
+
+// cjkDigits 中文数字
+var cjkDigits = map[rune]uint64{
+	'〇': 0, '零': 0, '一': 1, '二': 2, '两': 2, '兩': 2, '三': 3, '四': 4,
+	'五': 5, '六': 6, '七': 7, '八': 8, '九': 9,
+}
+
+// cjkUnits 中文数字的单位
+var cjkUnits = map[rune]uint64{
+	'十': 10, '拾': 10, '百': 100, '佰': 100, '千': 1000, '仟': 1000,
+	'万': 10000, '萬': 10000, '亿': 100000000, '億': 100000000,
+}
+
+// cjkCounters 中文数字后面的量词，只有带 第 前缀或这些量词时才把中文数字当作数字排序
+var cjkCounters = map[rune]bool{
+	'话': true, '話': true, '回': true, '卷': true, '巻': true, '章': true, '集': true,
+	'册': true, '冊': true, '部': true, '页': true, '頁': true, '篇': true, '期': true,
+}
+
+// isCJKNumeral 是否为中文数字或单位
+func isCJKNumeral(r rune) bool {
+	_, digit := cjkDigits[r]
+	_, unit := cjkUnits[r]
+	return digit || unit
+}
+
+// digitValue 获取数字字符的值，支持半角和全角数字
+func digitValue(r rune) (int, bool) {
+	switch {
+	case r >= '0' && r <= '9':
+		return int(r - '0'), true
+	case r >= '０' && r <= '９':
+		return int(r - '０'), true
+	}
+	return 0, false
+}
+
+// parseCJKNumber 将中文数字转换为十进制字符串，如 十二 -> 12、一二〇 -> 120
+func parseCJKNumber(runes []rune) string {
+	hasUnit := false
+	for _, r := range runes {
+		if _, ok := cjkUnits[r]; ok {
+			hasUnit = true
+			break
+		}
+	}
+
+	// 没有单位时逐位读出
+	if !hasUnit {
+		var b strings.Builder
+		for _, r := range runes {
+			b.WriteByte(byte('0' + cjkDigits[r]))
+		}
+		return b.String()
+	}
+
+	var total, section, number uint64
+	for _, r := range runes {
+		if digit, ok := cjkDigits[r]; ok {
+			number = digit
+			continue
+		}
+		unit := cjkUnits[r]
+		switch {
+		case unit == 100000000:
+			total = (total + section + number) * unit
+			section = 0
+		case unit == 10000:
+			section += number
+			if section == 0 {
+				section = 1
+			}
+			total += section * unit
+			section = 0
+		default:
+			if number == 0 {
+				number = 1
+			}
+			section += number * unit
+		}
+		number = 0
+	}
+
+	return fmt.Sprintf("%d", total+section+number)
+}
+
+// naturalChunk 自然排序的一个片段：文本或数字
+type naturalChunk struct {
+	text     string
+	isNumber bool
+	integer  string // 去掉前导零的整数部分
+	fraction string // 去掉末尾零的小数部分
+	zeros    int    // 前导零个数，数值相同时前导零少的排前面
+}
+
+// numberChunk 由数字字符串生成片段
+func numberChunk(integer, fraction string) naturalChunk {
+	trimmed := strings.TrimLeft(integer, "0")
+	return naturalChunk{
+		isNumber: true,
+		integer:  trimmed,
+		fraction: strings.TrimRight(fraction, "0"),
+		zeros:    len(integer) - len(trimmed),
+	}
+}
+
+// splitNatural 将字符串拆分为文本和数字片段
+// 支持全角数字、任意长度的数字、12.5 这类小数话数，以及 第十二话 这类中文数字
+func splitNatural(s string) []naturalChunk {
+	runes := []rune(s)
+	var chunks []naturalChunk
+	var text []rune
+
+	flushText := func() {
+		if len(text) > 0 {
+			chunks = append(chunks, naturalChunk{text: string(text)})
+			text = text[:0]
+		}
+	}
+
+	for i := 0; i < len(runes); {
+		if _, ok := digitValue(runes[i]); ok {
+			flushText()
+			var integer, fraction []byte
+			for ; i < len(runes); i++ {
+				d, ok := digitValue(runes[i])
+				if !ok {
+					break
+				}
+				integer = append(integer, byte('0'+d))
+			}
+			// 小数点后紧跟数字时作为小数
+			if i+1 < len(runes) && (runes[i] == '.' || runes[i] == '．') {
+				if _, ok := digitValue(runes[i+1]); ok {
+					for i++; i < len(runes); i++ {
+						d, ok := digitValue(runes[i])
+						if !ok {
+							break
+						}
+						fraction = append(fraction, byte('0'+d))
+					}
+				}
+			}
+			chunks = append(chunks, numberChunk(string(integer), string(fraction)))
+			continue
+		}
+
+		if isCJKNumeral(runes[i]) {
+			end := i
+			for end < len(runes) && isCJKNumeral(runes[end]) {
+				end++
+			}
+			prefixed := i > 0 && runes[i-1] == '第'
+			counted := end < len(runes) && cjkCounters[runes[end]]
+			if prefixed || counted {
+				flushText()
+				chunks = append(chunks, numberChunk(parseCJKNumber(runes[i:end]), ""))
+				i = end
+				continue
+			}
+		}
+
+		text = append(text, runes[i])
+		i++
+	}
+	flushText()
+
+	return chunks
+}
+
+// compareNumberChunks 比较两个数字片段，按位数和逐位比较，不会溢出
+func compareNumberChunks(x, y naturalChunk) int {
+	if len(x.integer) != len(y.integer) {
+		if len(x.integer) < len(y.integer) {
+			return -1
+		}
+		return 1
+	}
+	if result := strings.Compare(x.integer, y.integer); result != 0 {
+		return result
+	}
+	if result := strings.Compare(x.fraction, y.fraction); result != 0 {
+		return result
+	}
+	return 0
+}
+
+// naturalCompare 自然排序比较，返回 -1、0、1
+// 数字按数值比较，文本按当前语言忽略大小写比较，数字排在文本前面
+func naturalCompare(x, y string) int {
+	xChunks := splitNatural(x)
+	yChunks := splitNatural(y)
+
+	for i := 0; i < len(xChunks) && i < len(yChunks); i++ {
+		xc, yc := xChunks[i], yChunks[i]
+		switch {
+		case xc.isNumber && yc.isNumber:
+			if result := compareNumberChunks(xc, yc); result != 0 {
+				return result
+			}
+		case xc.isNumber:
+			return -1
+		case yc.isNumber:
+			return 1
+		default:
+			if result := compareText(xc.text, yc.text); result != 0 {
+				return result
+			}
+		}
+	}
+
+	if len(xChunks) != len(yChunks) {
+		if len(xChunks) < len(yChunks) {
+			return -1
+		}
+		return 1
+	}
+
+	// 数值完全相同时前导零少的排前面，再按原文保证顺序稳定
+	for i := range xChunks {
+		if xChunks[i].isNumber && xChunks[i].zeros != yChunks[i].zeros {
+			if xChunks[i].zeros < yChunks[i].zeros {
+				return -1
+			}
+			return 1
+		}
+	}
+	return strings.Compare(x, y)
+}
+
+// comparePagePaths 按目录逐级自然排序比较页面路径，内层压缩包视为一级目录
+// 同一目录下的文件排在其子目录前面，文件名比较时忽略扩展名
+func comparePagePaths(x, y string) int {
+	xParts := strings.Split(archivePathToSlash(strings.ReplaceAll(x, "\\", "/")), "/")
+	yParts := strings.Split(archivePathToSlash(strings.ReplaceAll(y, "\\", "/")), "/")
+	xDirs, xName := xParts[:len(xParts)-1], xParts[len(xParts)-1]
+	yDirs, yName := yParts[:len(yParts)-1], yParts[len(yParts)-1]
+
+	for i := 0; i < len(xDirs) && i < len(yDirs); i++ {
+		if xDirs[i] == yDirs[i] {
+			continue
+		}
+		if result := naturalCompare(xDirs[i], yDirs[i]); result != 0 {
+			return result
+		}
+	}
+	if len(xDirs) != len(yDirs) {
+		if len(xDirs) < len(yDirs) {
+			return -1
+		}
+		return 1
+	}
+
+	xBase := strings.TrimSuffix(xName, path.Ext(xName))
+	yBase := strings.TrimSuffix(yName, path.Ext(yName))
+	if result := naturalCompare(xBase, yBase); result != 0 {
+		return result
+	}
+	return strings.Compare(x, y)
+}
+
+// loadSortLocale 从配置中读取排序语言
+func (a *App) loadSortLocale() {
+	var locale string
+	found, err := a.loadSetting(sortLocaleSettingKey, &locale)
+	if err != nil {
+		fmt.Printf("读取排序语言失败: %v\n", err)
+		return
+	}
+	if !found {
+		return
+	}
+	if err := setNaturalSortLocale(locale); err != nil {
+		fmt.Printf("排序语言无效，使用默认语言: %v\n", err)
+	}
+}
+
+// GetSortLocale 获取文本排序使用的语言
+func (a *App) GetSortLocale() string {
+	naturalCollator.Lock()
+	defer naturalCollator.Unlock()
+	return naturalCollator.locale
+}
+
+// SetSortLocale 设置文本排序使用的语言，如 zh、ja、en
+// 已导入漫画的页面顺序需要重建页面索引才会更新
+func (a *App) SetSortLocale(locale string) error {
+	if err := setNaturalSortLocale(locale); err != nil {
+		return err
+	}
+	return a.saveSetting(sortLocaleSettingKey, a.GetSortLocale())
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"sort"
+	"testing"
+)
+
+// sign 把比较结果归一为 -1、0、1
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+	return 0
+}
+
+// TestNaturalCompare 数字按数值比较，支持全角数字、超长数字、小数和中文数字
+func TestNaturalCompare(t *testing.T) {
+	tests := []struct {
+		x, y string
+		want int
+	}{
+		{"2", "10", -1},
+		{"page2", "page10", -1},
+		{"page10", "page2", 1},
+		{"abc", "abc", 0},
+		{"a", "B", -1},
+		{"1", "a", -1},
+		{"vol1", "vol1a", -1},
+		{"01", "1", 1},
+		{"第１２话", "第3话", 1},
+		{"１０", "９", 1},
+		{"99999999999999999999", "100000000000000000000", -1},
+		{"123456789012345678901234567890", "123456789012345678901234567891", -1},
+		{"12.5", "13", -1},
+		{"12", "12.5", -1},
+		{"12.5", "12.10", 1},
+		{"第12．5话", "第12话", 1},
+		{"第三话", "第十二话", -1},
+		{"第二卷", "第十卷", -1},
+		{"第九十九话", "第一二〇话", -1},
+		{"第九千话", "第一万话", -1},
+		{"十二话", "三话", 1},
+	}
+
+	for _, tt := range tests {
+		if got := sign(naturalCompare(tt.x, tt.y)); got != tt.want {
+			t.Errorf("naturalCompare(%q, %q) = %d, want %d", tt.x, tt.y, got, tt.want)
+		}
+	}
+}
+
+// TestNaturalCompareSort 排序结果与阅读顺序一致
+func TestNaturalCompareSort(t *testing.T) {
+	names := []string{"第十话", "第2话", "第1.5话", "第一话", "第11话", "第３话"}
+	want := []string{"第一话", "第1.5话", "第2话", "第３话", "第十话", "第11话"}
+
+	sort.SliceStable(names, func(i, j int) bool { return naturalCompare(names[i], names[j]) < 0 })
+	for i := range want {
+		if names[i] != want[i] {
+			t.Fatalf("sorted = %q, want %q", names, want)
+		}
+	}
+}
+
+// TestParseCJKNumber 中文数字转换为十进制
+func TestParseCJKNumber(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"三", "3"},
+		{"十", "10"},
+		{"十二", "12"},
+		{"二十", "20"},
+		{"一二〇", "120"},
+		{"一百零五", "105"},
+		{"三千二百", "3200"},
+		{"十万", "100000"},
+		{"一万二千", "12000"},
+		{"两亿", "200000000"},
+	}
+
+	for _, tt := range tests {
+		if got := parseCJKNumber([]rune(tt.in)); got != tt.want {
+			t.Errorf("parseCJKNumber(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+// TestComparePagePaths 按目录逐级比较，同目录的文件排在子目录前面，忽略扩展名
+func TestComparePagePaths(t *testing.T) {
+	tests := []struct {
+		x, y string
+		want int
+	}{
+		{"a/2.jpg", "a/10.jpg", -1},
+		{"1.jpg", "a/1.jpg", -1},
+		{"z.jpg", "a/1.jpg", -1},
+		{"ch2/1.jpg", "ch10/1.jpg", -1},
+		{"ch10/1.jpg", "ch2/9.jpg", 1},
+		{"ch2\\1.jpg", "ch10\\1.jpg", -1},
+		{"page2.png", "page10.jpg", -1},
+		{"1.png", "1.jpg", 1},
+		{"vol2.cbz!1.jpg", "vol10.cbz!1.jpg", -1},
+		{"cover.jpg", "vol1.cbz!1.jpg", -1},
+		{"a/1.jpg", "a/1.jpg", 0},
+	}
+
+	for _, tt := range tests {
+		if got := sign(comparePagePaths(tt.x, tt.y)); got != tt.want {
+			t.Errorf("comparePagePaths(%q, %q) = %d, want %d", tt.x, tt.y, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+const (
+	// pageHashMaxDistance 页面感知哈希相差不超过该位数时视为同一页
+	// 不能超过 pageHashBands-1，否则挑选候选时可能漏掉相似的页面
+	pageHashMaxDistance = 3
+	// pageHashBands 挑选候选时把64位哈希分成的段数，每段16位
+	pageHashBands = 4
+	// defaultMaxPageDiff 查找近似重复时默认允许相差的页数
+	defaultMaxPageDiff = 3
+	// commonPageHashLimit 出现在过多漫画中的哈希片段（空白页、通用广告页）不用于挑选候选
+	commonPageHashLimit = 50
+)
+
+// comicPageHashes 漫画按页序排列的感知哈希，known标记该页是否有哈希
+type comicPageHashes struct {
+	id     int64
+	hashes []uint64
+	known  []bool
+}
+
+// pageDifference 两本漫画的逐页比较结果，记录各自多出的页码
+type pageDifference struct {
+	first      int64
+	second     int64
+	onlyFirst  []int
+	onlySecond []int
+}
+
+// loadAllPageHashes 读取所有漫画的页面感知哈希
+func (a *App) loadAllPageHashes() ([]comicPageHashes, error) {
+	rows, err := a.db.Query(`SELECT comic_id, COALESCE(phash, '') FROM images ORDER BY comic_id, page_index`)
+	if err != nil {
+		return nil, fmt.Errorf("查询页面哈希失败: %v", err)
+	}
+	defer rows.Close()
+
+	var comics []comicPageHashes
+	for rows.Next() {
+		var comicID int64
+		var phash string
+		if err := rows.Scan(&comicID, &phash); err != nil {
+			continue
+		}
+		if len(comics) == 0 || comics[len(comics)-1].id != comicID {
+			comics = append(comics, comicPageHashes{id: comicID})
+		}
+		hash, ok := parseHash(phash)
+		current := &comics[len(comics)-1]
+		current.hashes = append(current.hashes, hash)
+		current.known = append(current.known, ok)
+	}
+
+	return comics, nil
+}
+
+// alignPageHashes 按最长公共子序列对齐两本漫画的页面，返回各自未对齐的页码
+func alignPageHashes(x, y comicPageHashes) ([]int, []int) {
+	n, m := len(x.hashes), len(y.hashes)
+	same := func(i, j int) bool {
+		return x.known[i] && y.known[j] && hashDistance(x.hashes[i], y.hashes[j]) <= pageHashMaxDistance
+	}
+
+	// lcs[i][j] 为 x[i:] 与 y[j:] 的最长公共子序列长度
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if same(i, j) {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var onlyX, onlyY []int
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case same(i, j) && lcs[i][j] == lcs[i+1][j+1]+1:
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			onlyX = append(onlyX, i)
+			i++
+		default:
+			onlyY = append(onlyY, j)
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		onlyX = append(onlyX, i)
+	}
+	for ; j < m; j++ {
+		onlyY = append(onlyY, j)
+	}
+
+	return onlyX, onlyY
+}
+
+// pageHashCandidates 通过相同的页面哈希片段挑选可能重复的漫画对，避免两两比较整个书库
+// 哈希分为4段16位，相差不超过pageHashMaxDistance（3）位的两页至少有一段完全相同，一定会被选中
+func pageHashCandidates(comics []comicPageHashes, maxPageDiff int) [][2]int {
+	index := make(map[uint64][]int)
+	for c, comic := range comics {
+		seen := make(map[uint64]bool)
+		for i, hash := range comic.hashes {
+			if !comic.known[i] {
+				continue
+			}
+			for band := uint64(0); band < pageHashBands; band++ {
+				key := band<<16 | (hash>>(band*16))&0xffff
+				if !seen[key] {
+					seen[key] = true
+					index[key] = append(index[key], c)
+				}
+			}
+		}
+	}
+
+	shared := make(map[[2]int]int)
+	for _, members := range index {
+		if len(members) > commonPageHashLimit {
+			continue
+		}
+		for i := 0; i < len(members); i++ {
+			for j := i + 1; j < len(members); j++ {
+				shared[[2]int{members[i], members[j]}]++
+			}
+		}
+	}
+
+	var pairs [][2]int
+	for pair, count := range shared {
+		x, y := len(comics[pair[0]].hashes), len(comics[pair[1]].hashes)
+		if x-y > maxPageDiff || y-x > maxPageDiff {
+			continue
+		}
+		// 相同的片段至少达到较少一方页数的一半才进一步对齐
+		if count*2 >= min(x, y) {
+			pairs = append(pairs, pair)
+		}
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i][0] != pairs[j][0] {
+			return pairs[i][0] < pairs[j][0]
+		}
+		return pairs[i][1] < pairs[j][1]
+	})
+	return pairs
+}
+
+// comparePageSequences 逐页比较页面感知哈希，返回相差不超过maxPageDiff页的漫画对
+func (a *App) comparePageSequences(maxPageDiff int) ([]pageDifference, error) {
+	comics, err := a.loadAllPageHashes()
+	if err != nil {
+		return nil, err
+	}
+
+	var diffs []pageDifference
+	for _, pair := range pageHashCandidates(comics, maxPageDiff) {
+		first, second := comics[pair[0]], comics[pair[1]]
+		onlyFirst, onlySecond := alignPageHashes(first, second)
+		if len(onlyFirst)+len(onlySecond) > maxPageDiff {
+			continue
+		}
+		diffs = append(diffs, pageDifference{
+			first:      first.id,
+			second:     second.id,
+			onlyFirst:  onlyFirst,
+			onlySecond: onlySecond,
+		})
+	}
+
+	return diffs, nil
+}
+
+// findNearDuplicatePairs 按已保存的页面感知哈希查找只相差几页的漫画，返回每对漫画及各自多出的页码
+func (a *App) findNearDuplicatePairs(maxPageDiff int) ([]map[string]interface{}, error) {
+	diffs, err := a.comparePageSequences(maxPageDiff)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []map[string]interface{}
+	for _, diff := range diffs {
+		// 逐页完全一致的由 StartDuplicateScan 处理
+		if len(diff.onlyFirst) == 0 && len(diff.onlySecond) == 0 {
+			continue
+		}
+
+		comics, err := a.comicsByIDs([]int64{diff.first, diff.second})
+		if err != nil {
+			return nil, err
+		}
+		if len(comics) != 2 {
+			continue
+		}
+		first, second := comics[0], comics[1]
+		onlyFirst, onlySecond := diff.onlyFirst, diff.onlySecond
+		if first["id"] != diff.first {
+			onlyFirst, onlySecond = onlySecond, onlyFirst
+		}
+
+		result = append(result, map[string]interface{}{
+			"first":        first,
+			"second":       second,
+			"onlyInFirst":  nonNilPages(onlyFirst),
+			"onlyInSecond": nonNilPages(onlySecond),
+		})
+	}
+
+	return result, nil
+}
+
+// StartNearDuplicateScan 在后台查找只相差几页的漫画（缺页、多出广告页等），返回任务ID
+// maxPageDiff不大于0时使用默认值，任务结果为漫画对，包含first、second、onlyInFirst、onlyInSecond
+func (a *App) StartNearDuplicateScan(maxPageDiff int) (string, error) {
+	if a.db == nil {
+		return "", fmt.Errorf("数据库未初始化")
+	}
+	if maxPageDiff <= 0 {
+		maxPageDiff = defaultMaxPageDiff
+	}
+
+	ids, err := a.allComicIDs()
+	if err != nil {
+		return "", err
+	}
+
+	return a.startJob("near-duplicates", len(ids)+1, func(ctx context.Context, job *backgroundJob) error {
+		if err := a.updateAllComicHashes(ctx, job, ids); err != nil {
+			return err
+		}
+
+		pairs, err := a.findNearDuplicatePairs(maxPageDiff)
+		if err != nil {
+			return err
+		}
+		for _, pair := range pairs {
+			job.addResult(pair)
+		}
+		job.advance(fmt.Sprintf("找到 %d 对近似重复漫画", len(pairs)), nil)
+		return nil
+	}), nil
+}
+
+// nonNilPages 保证返回给前端的页码列表不为null
+func nonNilPages(pages []int) []int {
+	if pages == nil {
+		return []int{}
+	}
+	return pages
+}
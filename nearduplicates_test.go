@@ -0,0 +1,103 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// testPageHash 由序号生成分布均匀的64位哈希（splitmix64），不同序号的哈希几乎没有相同的片段
+func testPageHash(n uint64) uint64 {
+	z := n*0x9e3779b97f4a7c15 + 0x9e3779b97f4a7c15
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
+
+// testComic 用给定的哈希生成漫画，所有页面都有哈希
+func testComic(id int64, hashes ...uint64) comicPageHashes {
+	known := make([]bool, len(hashes))
+	for i := range known {
+		known[i] = true
+	}
+	return comicPageHashes{id: id, hashes: hashes, known: known}
+}
+
+// testPages 生成序号从start开始的count页哈希
+func testPages(start, count int) []uint64 {
+	hashes := make([]uint64, count)
+	for i := range hashes {
+		hashes[i] = testPageHash(uint64(start + i))
+	}
+	return hashes
+}
+
+// TestAlignPageHashes 按最长公共子序列对齐，返回各自多出的页码
+func TestAlignPageHashes(t *testing.T) {
+	a, b, c, d := testPageHash(1), testPageHash(2), testPageHash(3), testPageHash(4)
+	unknown := testComic(2, a, b)
+	unknown.known[1] = false
+
+	tests := []struct {
+		name  string
+		x, y  comicPageHashes
+		onlyX []int
+		onlyY []int
+	}{
+		{"identical", testComic(1, a, b, c), testComic(2, a, b, c), nil, nil},
+		{"missing page", testComic(1, a, b, c, d), testComic(2, a, c, d), []int{1}, nil},
+		{"extra page at end", testComic(1, a, b, c), testComic(2, a, b, c, d), nil, []int{3}},
+		{"extra page at start", testComic(1, d, a, b), testComic(2, a, b), []int{0}, nil},
+		{"within distance", testComic(1, a, b), testComic(2, a^0b111, b), nil, nil},
+		{"beyond distance", testComic(1, a, b), testComic(2, a^0b1111, b), []int{0}, []int{0}},
+		{"unknown hash never matches", testComic(1, a, b), unknown, []int{1}, []int{1}},
+		{"empty", testComic(1), testComic(2, a), nil, []int{0}},
+	}
+
+	for _, tt := range tests {
+		onlyX, onlyY := alignPageHashes(tt.x, tt.y)
+		if !reflect.DeepEqual(onlyX, tt.onlyX) || !reflect.DeepEqual(onlyY, tt.onlyY) {
+			t.Errorf("%s: alignPageHashes = %v %v, want %v %v", tt.name, onlyX, onlyY, tt.onlyX, tt.onlyY)
+		}
+	}
+}
+
+// TestPageHashCandidates 有足够多相同片段、页数相差不大的漫画才作为候选
+func TestPageHashCandidates(t *testing.T) {
+	// 每页在三个不同的片段各改一位，只剩一个片段完全相同，仍应被选中
+	flipped := testPages(0, 10)
+	for i := range flipped {
+		flipped[i] ^= 1 | 1<<16 | 1<<32
+	}
+
+	common := make([]comicPageHashes, commonPageHashLimit+1)
+	for i := range common {
+		common[i] = testComic(int64(i), testPageHash(0))
+	}
+
+	tests := []struct {
+		name        string
+		comics      []comicPageHashes
+		maxPageDiff int
+		want        [][2]int
+	}{
+		{"same pages", []comicPageHashes{testComic(1, testPages(0, 10)...), testComic(2, testPages(0, 10)...)}, 3, [][2]int{{0, 1}}},
+		{"one segment shared", []comicPageHashes{testComic(1, testPages(0, 10)...), testComic(2, flipped...)}, 3, [][2]int{{0, 1}}},
+		{"missing pages", []comicPageHashes{testComic(1, testPages(0, 10)...), testComic(2, testPages(0, 8)...)}, 3, [][2]int{{0, 1}}},
+		{"page count too different", []comicPageHashes{testComic(1, testPages(0, 10)...), testComic(2, testPages(0, 6)...)}, 3, nil},
+		{"unrelated", []comicPageHashes{testComic(1, testPages(0, 10)...), testComic(2, testPages(100, 10)...)}, 3, nil},
+		{"one shared page", []comicPageHashes{testComic(1, testPages(0, 10)...), testComic(2, append(testPages(100, 9), testPageHash(0))...)}, 3, nil},
+		{"common page ignored", common, 3, nil},
+		{
+			"sorted pairs",
+			[]comicPageHashes{testComic(1, testPages(0, 5)...), testComic(2, testPages(100, 5)...), testComic(3, testPages(0, 5)...), testComic(4, testPages(100, 5)...)},
+			0,
+			[][2]int{{0, 2}, {1, 3}},
+		},
+	}
+
+	for _, tt := range tests {
+		if got := pageHashCandidates(tt.comics, tt.maxPageDiff); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: pageHashCandidates = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,304 @@
+package main
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"fmt"
+	"image"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// archivePathSeparator 压缩包路径与条目名之间的分隔符，嵌套时可以出现多次：vol.zip!ch01.zip!001.jpg
+const archivePathSeparator = "!"
+
+// archivePathBoundary 只有紧跟在压缩包扩展名后面的"!"才是分隔符，Wow!.jpg 这类条目名中的"!"保持原样
+// 扩展名需要与archiveExtensions保持一致
+var archivePathBoundary = regexp.MustCompile(`(?i)\.(?:zip|cbz)!`)
+
+// maxNestedArchiveDepth 最多展开的压缩包嵌套层数
+const maxNestedArchiveDepth = 3
+
+// defaultArchiveCacheBytes 内层压缩包解压缓存的大小上限
+const defaultArchiveCacheBytes int64 = 2 << 30
+
+// archiveExtensions 作为内层压缩包展开的扩展名
+var archiveExtensions = map[string]bool{
+	".zip": true,
+	".cbz": true,
+}
+
+// isArchiveEntry 判断压缩包中的条目是否为内层压缩包
+func isArchiveEntry(name string) bool {
+	return archiveExtensions[strings.ToLower(path.Ext(name))]
+}
+
+// splitArchiveParts 在分隔符处拆分路径，不含分隔符时只有一段
+func splitArchiveParts(archivePath string) []string {
+	var parts []string
+	start := 0
+	for _, loc := range archivePathBoundary.FindAllStringIndex(archivePath, -1) {
+		parts = append(parts, archivePath[start:loc[1]-len(archivePathSeparator)])
+		start = loc[1]
+	}
+	return append(parts, archivePath[start:])
+}
+
+// cutArchivePath 在第一个分隔符处拆分，返回最外层压缩包路径和其余部分
+func cutArchivePath(archivePath string) (string, string, bool) {
+	loc := archivePathBoundary.FindStringIndex(archivePath)
+	if loc == nil {
+		return archivePath, "", false
+	}
+	return archivePath[:loc[1]-len(archivePathSeparator)], archivePath[loc[1]:], true
+}
+
+// isArchivePath 判断路径是否指向压缩包中的条目
+func isArchivePath(archivePath string) bool {
+	return archivePathBoundary.MatchString(archivePath)
+}
+
+// archivePathToSlash 将分隔符换成"/"，内层压缩包视为一级目录
+func archivePathToSlash(archivePath string) string {
+	return strings.Join(splitArchiveParts(archivePath), "/")
+}
+
+// splitArchivePath 拆分压缩包路径，返回最外层压缩包路径、中间的内层压缩包条目和最终的条目名
+func splitArchivePath(archivePath string) (string, []string, string, bool) {
+	parts := splitArchiveParts(archivePath)
+	if len(parts) < 2 {
+		return "", nil, "", false
+	}
+	return parts[0], parts[1 : len(parts)-1], parts[len(parts)-1], true
+}
+
+// findZipEntry 按条目名查找压缩包中的文件
+func findZipEntry(reader *zip.Reader, name string) *zip.File {
+	for _, file := range reader.File {
+		if file.Name == name {
+			return file
+		}
+	}
+	return nil
+}
+
+// archiveChain 依次打开的外层和内层压缩包，reader为最内层
+type archiveChain struct {
+	reader   *zip.Reader
+	readerAt io.ReaderAt
+	closers  []io.Closer
+	key      string // 用于内层压缩包缓存的标识
+}
+
+// Close 由内向外关闭所有打开的文件
+func (c *archiveChain) Close() error {
+	for i := len(c.closers) - 1; i >= 0; i-- {
+		c.closers[i].Close()
+	}
+	c.closers = nil
+	return nil
+}
+
+// openArchiveChain 打开压缩包及其中的内层压缩包
+func (a *App) openArchiveChain(zipPath string, inner []string) (*archiveChain, error) {
+	if len(inner) > maxNestedArchiveDepth {
+		return nil, fmt.Errorf("压缩包嵌套层数过多: %d", len(inner))
+	}
+
+	file, err := os.Open(zipPath)
+	if err != nil {
+		return nil, fmt.Errorf("打开zip文件失败: %v", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("读取zip文件信息失败: %v", err)
+	}
+	reader, err := zip.NewReader(file, info.Size())
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("打开zip文件失败: %v", err)
+	}
+
+	chain := &archiveChain{
+		reader:   reader,
+		readerAt: file,
+		closers:  []io.Closer{file},
+		key:      zipPath + "\x00" + sourceVersion(zipPath),
+	}
+	for _, name := range inner {
+		entry := findZipEntry(chain.reader, name)
+		if entry == nil {
+			chain.Close()
+			return nil, fmt.Errorf("zip中找不到内层压缩包: %s", name)
+		}
+		if err := a.enterInnerArchive(chain, entry); err != nil {
+			chain.Close()
+			return nil, err
+		}
+	}
+
+	return chain, nil
+}
+
+// enterInnerArchive 将chain切换到内层压缩包
+// 未压缩存储的内层压缩包直接在外层文件上按区间读取；压缩过的需要随机访问，解压到缓存目录后打开
+func (a *App) enterInnerArchive(chain *archiveChain, entry *zip.File) error {
+	size := int64(entry.UncompressedSize64)
+	key := chain.key + "\x00" + entry.Name
+
+	if entry.Method == zip.Store {
+		if offset, err := entry.DataOffset(); err == nil {
+			section := io.NewSectionReader(chain.readerAt, offset, size)
+			if reader, err := zip.NewReader(section, size); err == nil {
+				chain.reader, chain.readerAt, chain.key = reader, section, key
+				return nil
+			}
+		}
+	}
+
+	var readerAt io.ReaderAt
+	cacheKey := pageCacheKey(key)
+	cachedPath, ok := a.archiveCache.cachedFile(cacheKey, ".zip")
+	if !ok {
+		rc, err := entry.Open()
+		if err != nil {
+			return fmt.Errorf("打开内层压缩包失败: %v", err)
+		}
+		cachedPath, err = a.archiveCache.storeFile(cacheKey, ".zip", rc)
+		if err != nil {
+			// 没有可用的缓存目录时解压到内存
+			rc.Close()
+			if rc, err = entry.Open(); err != nil {
+				return fmt.Errorf("打开内层压缩包失败: %v", err)
+			}
+			data, err := io.ReadAll(rc)
+			rc.Close()
+			if err != nil {
+				return fmt.Errorf("解压内层压缩包失败: %v", err)
+			}
+			readerAt = bytes.NewReader(data)
+		} else {
+			rc.Close()
+		}
+	}
+
+	if readerAt == nil {
+		file, err := os.Open(cachedPath)
+		if err != nil {
+			return fmt.Errorf("打开内层压缩包缓存失败: %v", err)
+		}
+		chain.closers = append(chain.closers, file)
+		readerAt = file
+	}
+
+	reader, err := zip.NewReader(readerAt, size)
+	if err != nil {
+		return fmt.Errorf("打开内层压缩包失败: %v", err)
+	}
+	chain.reader, chain.readerAt, chain.key = reader, readerAt, key
+	return nil
+}
+
+// openArchiveEntry 按 zip路径!内层压缩包!条目名 打开条目，关闭时一并关闭所有压缩包
+func (a *App) openArchiveEntry(archivePath string) (io.ReadCloser, *zip.File, error) {
+	zipPath, inner, name, ok := splitArchivePath(archivePath)
+	if !ok {
+		return nil, nil, fmt.Errorf("无效的压缩包路径: %s", archivePath)
+	}
+
+	chain, err := a.openArchiveChain(zipPath, inner)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	entry := findZipEntry(chain.reader, name)
+	if entry == nil {
+		chain.Close()
+		return nil, nil, fmt.Errorf("zip中找不到图片: %s", name)
+	}
+	rc, err := entry.Open()
+	if err != nil {
+		chain.Close()
+		return nil, nil, fmt.Errorf("打开图片文件失败: %v", err)
+	}
+
+	return &zipEntryReader{ReadCloser: rc, archive: chain}, entry, nil
+}
+
+// collectArchivePages 收集压缩包及其内层压缩包中的页面（未排序），页面名为相对最外层压缩包的嵌套路径
+func (a *App) collectArchivePages(zipPath string, inner []string, chain *archiveChain, matcher *ignoreMatcher) []comicPage {
+	prefix := ""
+	if len(inner) > 0 {
+		prefix = strings.Join(inner, archivePathSeparator) + archivePathSeparator
+	}
+
+	var pages []comicPage
+	for _, name := range a.bfsSearchImages(chain.reader.File, matcher) {
+		page := comicPage{Name: prefix + name, Path: zipPath + archivePathSeparator + prefix + name}
+		page.Chapter = pageChapter(page.Name)
+		if file := findZipEntry(chain.reader, name); file != nil {
+			page.FileSize = int64(file.UncompressedSize64)
+			if rc, err := file.Open(); err == nil {
+				// 只解析图片头部获取尺寸
+				if config, _, err := image.DecodeConfig(rc); err == nil {
+					page.Width, page.Height = config.Width, config.Height
+				}
+				rc.Close()
+			}
+		}
+		pages = append(pages, page)
+	}
+
+	if len(inner) >= maxNestedArchiveDepth {
+		return pages
+	}
+
+	for _, file := range chain.reader.File {
+		if !isArchiveEntry(file.Name) || matcher.ignored(file.Name) {
+			continue
+		}
+
+		nested := append(append([]string{}, inner...), file.Name)
+		child := &archiveChain{reader: chain.reader, readerAt: chain.readerAt, key: chain.key}
+		if err := a.enterInnerArchive(child, file); err != nil {
+			fmt.Printf("读取内层压缩包 %s 失败: %v\n", strings.Join(nested, archivePathSeparator), err)
+			child.Close()
+			continue
+		}
+		pages = append(pages, a.collectArchivePages(zipPath, nested, child, matcher)...)
+		child.Close()
+	}
+
+	return pages
+}
+
+// handleNestedZipImage 处理内层压缩包中的图片请求
+func (h *FileLoader) handleNestedZipImage(res http.ResponseWriter, archivePath string) {
+	rc, entry, err := h.app.openArchiveEntry(archivePath)
+	if err != nil {
+		res.WriteHeader(http.StatusNotFound)
+		res.Write([]byte(err.Error()))
+		return
+	}
+	defer rc.Close()
+
+	reader := bufio.NewReader(rc)
+	header, _ := reader.Peek(sniffHeaderSize)
+	if format := detectModernImageFormat(header); format != "" && !webviewDisplayable[format] {
+		h.serveTransformedImage(res, comicPage{Name: entry.Name, Path: archivePath}, imageTransform{}, nil)
+		return
+	}
+
+	res.Header().Set("Content-Type", pageContentType(header, entry.Name))
+	res.Header().Set("Cache-Control", "public, max-age=3600")
+	res.Header().Set("Content-Length", fmt.Sprintf("%d", entry.UncompressedSize64))
+	if _, err := io.Copy(res, reader); err != nil {
+		println("Error streaming nested image:", err.Error())
+	}
+}
@@ -0,0 +1,277 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+
+	_ "golang.org/x/image/bmp"
+	_ "golang.org/x/image/tiff"
+)
+
+// comicPage 漫画中的一页
+type comicPage struct {
+	Index    int
+	Name     string // zip中的条目名或文件夹中的相对路径
+	Path     string // 前端访问用的路径：zip为 zip路径!条目名，文件夹为绝对路径
+	FileSize int64
+	Width    int
+	Height   int
+	Chapter  string // 页面所在的子目录，根目录为空
+	PHash    string // 页面的感知哈希，查找重复时才计算，未计算或解码失败时为空
+}
+
+// createPageIndexColumns 为图片表添加页码字段
+func (a *App) createPageIndexColumns() error {
+	if err := a.ensureColumn("images", "page_index", "INTEGER DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := a.ensureColumn("images", "chapter", "TEXT DEFAULT ''"); err != nil {
+		return err
+	}
+
+	_, err := a.db.Exec(`CREATE INDEX IF NOT EXISTS idx_images_comic_page ON images (comic_id, page_index)`)
+	if err != nil {
+		return fmt.Errorf("创建images索引失败: %v", err)
+	}
+
+	return nil
+}
+
+// pageChapter 获取页面所在的子目录或内层压缩包作为章节，name为以 / 分隔的相对路径
+func pageChapter(name string) string {
+	dir := path.Dir(archivePathToSlash(name))
+	if dir == "." {
+		return ""
+	}
+	return dir
+}
+
+// listZipPages 按自然顺序列出zip中的所有页面并读取图片尺寸，内层压缩包中的页面一并列出
+func (a *App) listZipPages(zipPath string) ([]comicPage, error) {
+	return a.scanZipPages(zipPath, a.ignoreMatcherFor(zipPath))
+}
+
+// scanZipPages 按指定的忽略规则列出zip中的页面，matcher为nil时不排除任何条目
+func (a *App) scanZipPages(zipPath string, matcher *ignoreMatcher) ([]comicPage, error) {
+	chain, err := a.openArchiveChain(zipPath, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer chain.Close()
+
+	pages := a.collectArchivePages(zipPath, nil, chain, matcher)
+	sort.Slice(pages, func(i, j int) bool {
+		return a.naturalSort(pages[i].Name, pages[j].Name)
+	})
+	for i := range pages {
+		pages[i].Index = i
+	}
+
+	return pages, nil
+}
+
+// listFolderPages 按自然顺序列出文件夹中的所有页面并读取图片尺寸
+func (a *App) listFolderPages(folderPath string) ([]comicPage, error) {
+	return a.scanFolderPages(folderPath, a.ignoreMatcherFor(folderPath))
+}
+
+// scanFolderPages 按指定的忽略规则列出文件夹中的页面，matcher为nil时不排除任何文件
+func (a *App) scanFolderPages(folderPath string, matcher *ignoreMatcher) ([]comicPage, error) {
+	imageFiles, err := a.bfsSearchImagesFromFolder(folderPath, matcher)
+	if err != nil {
+		return nil, fmt.Errorf("搜索文件夹失败: %v", err)
+	}
+	sort.Slice(imageFiles, func(i, j int) bool {
+		return a.naturalSort(imageFiles[i], imageFiles[j])
+	})
+
+	pages := make([]comicPage, 0, len(imageFiles))
+	for i, path := range imageFiles {
+		name, err := filepath.Rel(folderPath, path)
+		if err != nil {
+			name = filepath.Base(path)
+		}
+		page := comicPage{Index: i, Name: filepath.ToSlash(name), Path: path, Chapter: pageChapter(filepath.ToSlash(name))}
+		if f, err := os.Open(path); err == nil {
+			if info, err := f.Stat(); err == nil {
+				page.FileSize = info.Size()
+			}
+			if config, _, err := image.DecodeConfig(f); err == nil {
+				page.Width, page.Height = config.Width, config.Height
+			}
+			f.Close()
+		}
+		pages = append(pages, page)
+	}
+
+	return pages, nil
+}
+
+// listComicPages 根据漫画类型列出所有页面
+func (a *App) listComicPages(filePath, fileType string) ([]comicPage, error) {
+	switch fileType {
+	case "zip":
+		return a.listZipPages(filePath)
+	case "folder":
+		return a.listFolderPages(filePath)
+	default:
+		return nil, fmt.Errorf("不支持的漫画类型: %s", fileType)
+	}
+}
+
+// indexComicPages 重新建立漫画的页面索引并写入images表
+func (a *App) indexComicPages(comicID int64) error {
+	filePath, fileType, err := a.getComicPath(comicID)
+	if err != nil {
+		return err
+	}
+
+	pages, err := a.listComicPages(filePath, fileType)
+	if err != nil {
+		return err
+	}
+
+	tx, err := a.db.Begin()
+	if err != nil {
+		return fmt.Errorf("开启事务失败: %v", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`DELETE FROM images WHERE comic_id = ?`, comicID)
+	if err != nil {
+		return fmt.Errorf("清除页面索引失败: %v", err)
+	}
+
+	stmt, err := tx.Prepare(`INSERT INTO images (comic_id, page_index, file_name, file_path, file_size, width, height, chapter) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return fmt.Errorf("准备插入页面索引失败: %v", err)
+	}
+	defer stmt.Close()
+
+	for _, page := range pages {
+		_, err := stmt.Exec(comicID, page.Index, page.Name, page.Path, page.FileSize, page.Width, page.Height, page.Chapter)
+		if err != nil {
+			return fmt.Errorf("写入页面索引失败: %v", err)
+		}
+	}
+
+	// 页面哈希和感知哈希随索引一起清除，查找重复时重新计算
+	_, err = tx.Exec(`UPDATE comics SET page_count = ?, hash_version = '' WHERE id = ?`, len(pages), comicID)
+	if err != nil {
+		return fmt.Errorf("更新页数失败: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交事务失败: %v", err)
+	}
+
+	fmt.Printf("已建立页面索引 %s，共 %d 页\n", filePath, len(pages))
+	return nil
+}
+
+// loadComicPages 从images表读取页面索引，没有索引时先建立
+func (a *App) loadComicPages(comicID int64) ([]comicPage, error) {
+	if a.db == nil {
+		return nil, fmt.Errorf("数据库未初始化")
+	}
+
+	var count int
+	err := a.db.QueryRow(`SELECT COUNT(*) FROM images WHERE comic_id = ?`, comicID).Scan(&count)
+	if err != nil {
+		return nil, fmt.Errorf("查询页面索引失败: %v", err)
+	}
+	if count == 0 {
+		if err := a.indexComicPages(comicID); err != nil {
+			return nil, err
+		}
+	}
+
+	rows, err := a.db.Query(`SELECT page_index, file_name, file_path, COALESCE(file_size, 0), COALESCE(width, 0), COALESCE(height, 0), COALESCE(chapter, ''), COALESCE(phash, '')
+		FROM images WHERE comic_id = ? ORDER BY page_index`, comicID)
+	if err != nil {
+		return nil, fmt.Errorf("查询页面索引失败: %v", err)
+	}
+	defer rows.Close()
+
+	var pages []comicPage
+	for rows.Next() {
+		var page comicPage
+		if err := rows.Scan(&page.Index, &page.Name, &page.Path, &page.FileSize, &page.Width, &page.Height, &page.Chapter, &page.PHash); err != nil {
+			continue
+		}
+		pages = append(pages, page)
+	}
+
+	return pages, nil
+}
+
+// pagesToMaps 将页面列表转换为前端使用的map
+func pagesToMaps(pages []comicPage) []map[string]interface{} {
+	result := make([]map[string]interface{}, 0, len(pages))
+	for _, page := range pages {
+		result = append(result, map[string]interface{}{
+			"index":    page.Index,
+			"name":     page.Name,
+			"path":     page.Path,
+			"fileSize": page.FileSize,
+			"width":    page.Width,
+			"height":   page.Height,
+			"chapter":  page.Chapter,
+		})
+	}
+	return result
+}
+
+// GetComicPages 获取漫画的页面列表
+func (a *App) GetComicPages(comicID int64) ([]map[string]interface{}, error) {
+	pages, err := a.loadComicPages(comicID)
+	if err != nil {
+		return nil, err
+	}
+
+	return pagesToMaps(pages), nil
+}
+
+// GetComicChapters 获取漫画的章节划分，每个子目录为一章，返回章节名、起始页和页数
+func (a *App) GetComicChapters(comicID int64) ([]map[string]interface{}, error) {
+	pages, err := a.loadComicPages(comicID)
+	if err != nil {
+		return nil, err
+	}
+
+	var chapters []map[string]interface{}
+	for _, page := range pages {
+		if len(chapters) > 0 && chapters[len(chapters)-1]["chapter"] == page.Chapter {
+			chapters[len(chapters)-1]["pageCount"] = chapters[len(chapters)-1]["pageCount"].(int) + 1
+			continue
+		}
+		name := ""
+		if page.Chapter != "" {
+			name = path.Base(page.Chapter)
+		}
+		chapters = append(chapters, map[string]interface{}{
+			"chapter":   page.Chapter,
+			"name":      name,
+			"startPage": page.Index,
+			"pageCount": 1,
+		})
+	}
+
+	return chapters, nil
+}
+
+// ReindexComicPages 重新扫描漫画文件并建立页面索引
+func (a *App) ReindexComicPages(comicID int64) error {
+	if a.db == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+
+	return a.indexComicPages(comicID)
+}
@@ -0,0 +1,472 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+// comicPageRoutePrefix 按漫画ID和页码访问页面的路由前缀
+const comicPageRoutePrefix = "/comic/"
+
+// 半页参数
+const (
+	pageHalfLeft  = "left"
+	pageHalfRight = "right"
+)
+
+// pageCropAuto 自动裁剪页面白边的参数值
+const pageCropAuto = "auto"
+
+// defaultJPEGQuality 重新编码JPEG时默认使用的质量
+const defaultJPEGQuality = 90
+
+// encodableFormats 可以输出的图片格式，webp需要安装外部编码工具
+var encodableFormats = map[string]string{
+	"jpeg": "jpeg",
+	"jpg":  "jpeg",
+	"png":  "png",
+	"webp": "webp",
+}
+
+// imageTransform 页面的处理参数，全部为零值时直接返回原图
+type imageTransform struct {
+	Half      string
+	Crop      bool
+	MaxWidth  int
+	MaxHeight int
+	Format    string
+	Quality   int
+}
+
+// parseImageTransform 解析 half、crop、w、h、format、quality 查询参数
+func parseImageTransform(query url.Values) (imageTransform, error) {
+	var t imageTransform
+
+	t.Half = query.Get("half")
+	if t.Half != "" && t.Half != pageHalfLeft && t.Half != pageHalfRight {
+		return t, fmt.Errorf("Invalid half: %s", t.Half)
+	}
+
+	if crop := query.Get("crop"); crop != "" {
+		if crop != pageCropAuto {
+			return t, fmt.Errorf("Invalid crop: %s", crop)
+		}
+		t.Crop = true
+	}
+
+	for _, item := range []struct {
+		name   string
+		target *int
+		max    int
+	}{
+		{"w", &t.MaxWidth, 20000},
+		{"h", &t.MaxHeight, 20000},
+		{"quality", &t.Quality, 100},
+	} {
+		value := query.Get(item.name)
+		if value == "" {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > item.max {
+			return t, fmt.Errorf("Invalid %s: %s", item.name, value)
+		}
+		*item.target = n
+	}
+
+	if format := strings.ToLower(query.Get("format")); format != "" {
+		encoded, ok := encodableFormats[format]
+		if !ok || (encoded == "webp" && !webpEncoderAvailable()) {
+			return t, fmt.Errorf("Unsupported format: %s", format)
+		}
+		t.Format = encoded
+	}
+
+	return t, nil
+}
+
+// isIdentity 是否不需要任何处理
+func (t imageTransform) isIdentity() bool {
+	return t == imageTransform{}
+}
+
+// cacheKey 处理参数在缓存键中的表示
+func (t imageTransform) cacheKey() string {
+	return fmt.Sprintf("half=%s&crop=%v&w=%d&h=%d&format=%s&quality=%d", t.Half, t.Crop, t.MaxWidth, t.MaxHeight, t.Format, t.Quality)
+}
+
+// parseComicPageRoute 解析 /comic/<id>/page/<n> 形式的路径
+func parseComicPageRoute(path string) (int64, int, error) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(path, comicPageRoutePrefix), "/"), "/")
+	if len(parts) != 3 || parts[1] != "page" {
+		return 0, 0, fmt.Errorf("无效的页面路径: %s", path)
+	}
+
+	comicID, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("无效的漫画ID: %s", parts[0])
+	}
+	pageIndex, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return 0, 0, fmt.Errorf("无效的页码: %s", parts[2])
+	}
+
+	return comicID, pageIndex, nil
+}
+
+// comicPageURL 生成页面的访问路径
+func comicPageURL(comicID int64, pageIndex int, half string) string {
+	pageURL := fmt.Sprintf("%s%d/page/%d", comicPageRoutePrefix, comicID, pageIndex)
+	if half != "" {
+		pageURL += "?half=" + half
+	}
+	return pageURL
+}
+
+// imageContentType 根据扩展名获取图片的MIME类型
+func imageContentType(name string) string {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".jpg", ".jpeg":
+		return "image/jpeg"
+	case ".png":
+		return "image/png"
+	case ".gif":
+		return "image/gif"
+	case ".bmp":
+		return "image/bmp"
+	case ".webp":
+		return "image/webp"
+	case ".tiff", ".tif":
+		return "image/tiff"
+	case ".avif":
+		return "image/avif"
+	case ".jxl":
+		return "image/jxl"
+	case ".heic", ".heif":
+		return "image/heif"
+	default:
+		return "image/jpeg"
+	}
+}
+
+// openPage 打开页面数据流，zip中的页面路径形如 zip路径!条目名，嵌套压缩包为 zip路径!内层.zip!条目名
+func (a *App) openPage(page comicPage) (io.ReadCloser, error) {
+	if !isArchivePath(page.Path) {
+		file, err := os.Open(page.Path)
+		if err != nil {
+			return nil, fmt.Errorf("打开图片文件失败: %v", err)
+		}
+		return file, nil
+	}
+
+	rc, _, err := a.openArchiveEntry(page.Path)
+	return rc, err
+}
+
+// zipEntryReader 关闭条目时一并关闭所在的zip文件
+type zipEntryReader struct {
+	io.ReadCloser
+	archive io.Closer
+}
+
+func (r *zipEntryReader) Close() error {
+	err := r.ReadCloser.Close()
+	r.archive.Close()
+	return err
+}
+
+// getComicPage 获取漫画的指定页
+func (a *App) getComicPage(comicID int64, pageIndex int) (comicPage, error) {
+	pages, err := a.loadComicPages(comicID)
+	if err != nil {
+		return comicPage{}, err
+	}
+	if pageIndex < 0 || pageIndex >= len(pages) {
+		return comicPage{}, fmt.Errorf("页码超出范围: %d，共 %d 页", pageIndex, len(pages))
+	}
+	return pages[pageIndex], nil
+}
+
+// cropImage 裁剪图片的指定区域
+func cropImage(img image.Image, rect image.Rectangle) image.Image {
+	if sub, ok := img.(interface {
+		SubImage(r image.Rectangle) image.Image
+	}); ok {
+		return sub.SubImage(rect)
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, rect.Dx(), rect.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, rect.Min, draw.Src)
+	return dst
+}
+
+// splitImageHalf 取跨页的左半或右半部分
+func splitImageHalf(img image.Image, half string) image.Image {
+	bounds := img.Bounds()
+	mid := bounds.Min.X + bounds.Dx()/2
+	if half == pageHalfLeft {
+		return cropImage(img, image.Rect(bounds.Min.X, bounds.Min.Y, mid, bounds.Max.Y))
+	}
+	return cropImage(img, image.Rect(mid, bounds.Min.Y, bounds.Max.X, bounds.Max.Y))
+}
+
+// resizeToFit 按比例缩小图片使其不超过最大宽高，不会放大
+func resizeToFit(img image.Image, maxWidth, maxHeight int) image.Image {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	scale := 1.0
+	if maxWidth > 0 && width > maxWidth {
+		scale = float64(maxWidth) / float64(width)
+	}
+	if maxHeight > 0 && height > maxHeight {
+		if s := float64(maxHeight) / float64(height); s < scale {
+			scale = s
+		}
+	}
+	if scale >= 1 {
+		return img
+	}
+
+	dstWidth := int(float64(width)*scale + 0.5)
+	dstHeight := int(float64(height)*scale + 0.5)
+	if dstWidth < 1 {
+		dstWidth = 1
+	}
+	if dstHeight < 1 {
+		dstHeight = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dstWidth, dstHeight))
+	xdraw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, xdraw.Src, nil)
+	return dst
+}
+
+// encodePageImage 编码图片，format为png时保持无损，webp调用外部工具编码，其余格式输出JPEG
+func encodePageImage(img image.Image, format string, quality int) ([]byte, string, error) {
+	var buf bytes.Buffer
+	if format == "png" {
+		if err := png.Encode(&buf, img); err != nil {
+			return nil, "", fmt.Errorf("编码PNG失败: %v", err)
+		}
+		return buf.Bytes(), "image/png", nil
+	}
+
+	if quality <= 0 {
+		quality = defaultJPEGQuality
+	}
+	if format == "webp" {
+		data, err := encodeWebPWithExternalTool(img, quality, false)
+		if err != nil {
+			return nil, "", err
+		}
+		return data, "image/webp", nil
+	}
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+		return nil, "", fmt.Errorf("编码JPEG失败: %v", err)
+	}
+	return buf.Bytes(), "image/jpeg", nil
+}
+
+// transformImage 解码并依次执行裁剪、切分、缩放和编码
+// cropBox为空时使用不带缓存的白边检测
+func transformImage(src io.Reader, t imageTransform, cropBox func(image.Image) image.Rectangle) ([]byte, string, error) {
+	img, format, err := image.Decode(src)
+	if err != nil {
+		return nil, "", fmt.Errorf("Could not decode page: %s", err.Error())
+	}
+
+	// 先裁掉白边再切分，切分位置取内容区域的中线
+	if t.Crop {
+		var box image.Rectangle
+		if cropBox != nil {
+			box = cropBox(img)
+		} else {
+			box = detectCropBox(img)
+		}
+		if !box.Empty() {
+			img = cropImage(img, box)
+		}
+	}
+	if t.Half != "" && img.Bounds().Dx() > img.Bounds().Dy() {
+		img = splitImageHalf(img, t.Half)
+	}
+	if t.MaxWidth > 0 || t.MaxHeight > 0 {
+		img = resizeToFit(img, t.MaxWidth, t.MaxHeight)
+	}
+
+	outputFormat := t.Format
+	if outputFormat == "" && format == "png" {
+		outputFormat = "png"
+	}
+
+	return encodePageImage(img, outputFormat, t.Quality)
+}
+
+// sourceVersion 用图片所在文件的修改时间和大小标识版本，文件变化后缓存自动失效
+func sourceVersion(path string) string {
+	container, _, _ := cutArchivePath(path)
+	info, err := os.Stat(container)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%d-%d", info.ModTime().UnixNano(), info.Size())
+}
+
+// serveTransformedImage 在工作池中处理图片并写入磁盘缓存，命中缓存时直接返回
+func (h *FileLoader) serveTransformedImage(res http.ResponseWriter, page comicPage, t imageTransform, cropBox func(image.Image) image.Rectangle) {
+	key := pageCacheKey(page.Path, sourceVersion(page.Path), t.cacheKey())
+
+	data, contentType, ok := h.app.pageCache.Get(key)
+	if !ok {
+		err := h.app.imagePool.Do(func() error {
+			rc, err := h.app.openPage(page)
+			if err != nil {
+				return err
+			}
+			defer rc.Close()
+
+			data, contentType, err = transformImage(rc, t, cropBox)
+			return err
+		})
+		if err != nil {
+			res.WriteHeader(http.StatusInternalServerError)
+			res.Write([]byte(err.Error()))
+			return
+		}
+		h.app.pageCache.Put(key, data, contentType)
+	}
+
+	res.Header().Set("Content-Type", contentType)
+	res.Header().Set("Cache-Control", "public, max-age=3600")
+	res.Header().Set("Content-Length", fmt.Sprintf("%d", len(data)))
+	res.Write(data)
+}
+
+// handleTransformedFile 处理带缩放、转码等参数的 zip路径!条目名 或普通文件请求
+func (h *FileLoader) handleTransformedFile(res http.ResponseWriter, req *http.Request, t imageTransform) {
+	path := req.URL.Path
+	h.serveTransformedImage(res, comicPage{Name: filepath.Base(path), Path: path}, t, nil)
+}
+
+// handleComicPage 处理 /comic/<id>/page/<n> 请求
+// half=left|right 时只返回跨页的一半，crop=auto 时裁掉页面四周的白边或黑边，
+// w、h 限制最大宽高，format、quality 指定输出格式和质量
+func (h *FileLoader) handleComicPage(res http.ResponseWriter, req *http.Request) {
+	comicID, pageIndex, err := parseComicPageRoute(req.URL.Path)
+	if err != nil {
+		res.WriteHeader(http.StatusBadRequest)
+		res.Write([]byte(err.Error()))
+		return
+	}
+
+	t, err := parseImageTransform(req.URL.Query())
+	if err != nil {
+		res.WriteHeader(http.StatusBadRequest)
+		res.Write([]byte(err.Error()))
+		return
+	}
+
+	page, err := h.app.getComicPage(comicID, pageIndex)
+	if err != nil {
+		res.WriteHeader(http.StatusNotFound)
+		res.Write([]byte(err.Error()))
+		return
+	}
+
+	// 非跨页不需要切分
+	if !isSpreadPage(page) {
+		t.Half = ""
+	}
+
+	if !t.isIdentity() {
+		cropBox := func(img image.Image) image.Rectangle {
+			box, err := h.app.pageCropBox(comicID, pageIndex, img)
+			if err != nil {
+				println("Error detecting crop box:", err.Error())
+			}
+			return box
+		}
+		h.serveTransformedImage(res, page, t, cropBox)
+		return
+	}
+
+	// 不需要处理时直接以流的方式返回原图
+	rc, err := h.app.openPage(page)
+	if err != nil {
+		res.WriteHeader(http.StatusInternalServerError)
+		res.Write([]byte(err.Error()))
+		return
+	}
+	defer rc.Close()
+
+	// 根据文件头识别新格式，webview无法显示时转码
+	reader := bufio.NewReader(rc)
+	header, _ := reader.Peek(sniffHeaderSize)
+	format := detectModernImageFormat(header)
+	if format != "" && !webviewDisplayable[format] {
+		h.serveTransformedImage(res, page, t, nil)
+		return
+	}
+
+	res.Header().Set("Content-Type", pageContentType(header, page.Name))
+	res.Header().Set("Cache-Control", "public, max-age=3600")
+	if page.FileSize > 0 {
+		res.Header().Set("Content-Length", fmt.Sprintf("%d", page.FileSize))
+	}
+	if _, err := io.Copy(res, reader); err != nil {
+		println("Error streaming page:", err.Error())
+	}
+}
+
+// GetSplitPages 获取单页阅读用的虚拟页面列表，跨页拆成两半并按阅读方向排列
+func (a *App) GetSplitPages(comicID int64) ([]map[string]interface{}, error) {
+	pages, err := a.loadComicPages(comicID)
+	if err != nil {
+		return nil, err
+	}
+
+	mode, _, err := a.resolveReadingMode(comicID, pages)
+	if err != nil {
+		return nil, err
+	}
+
+	halves := []string{pageHalfLeft, pageHalfRight}
+	if mode == readingModeRTL {
+		halves = []string{pageHalfRight, pageHalfLeft}
+	}
+
+	var virtualPages []map[string]interface{}
+	for _, page := range pages {
+		if !isSpreadPage(page) {
+			virtualPages = append(virtualPages, map[string]interface{}{
+				"pageIndex": page.Index,
+				"half":      "",
+				"url":       comicPageURL(comicID, page.Index, ""),
+			})
+			continue
+		}
+		for _, half := range halves {
+			virtualPages = append(virtualPages, map[string]interface{}{
+				"pageIndex": page.Index,
+				"half":      half,
+				"url":       comicPageURL(comicID, page.Index, half),
+			})
+		}
+	}
+
+	return virtualPages, nil
+}
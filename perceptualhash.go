@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"math/bits"
+	"strconv"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+// dHash 计算图片的差值哈希：缩小为9x8灰度图，比较每行相邻像素的亮度得到64位
+// 对缩放、重新压缩和轻微调色不敏感
+func dHash(img image.Image) uint64 {
+	small := image.NewGray(image.Rect(0, 0, 9, 8))
+	xdraw.BiLinear.Scale(small, small.Bounds(), img, img.Bounds(), xdraw.Src, nil)
+
+	var hash uint64
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			hash <<= 1
+			if small.GrayAt(x, y).Y < small.GrayAt(x+1, y).Y {
+				hash |= 1
+			}
+		}
+	}
+	return hash
+}
+
+// formatHash 将哈希格式化为16位十六进制字符串保存
+func formatHash(hash uint64) string {
+	return fmt.Sprintf("%016x", hash)
+}
+
+// parseHash 解析保存的十六进制哈希
+func parseHash(s string) (uint64, bool) {
+	if s == "" {
+		return 0, false
+	}
+	hash, err := strconv.ParseUint(s, 16, 64)
+	return hash, err == nil
+}
+
+// hashDistance 两个哈希不同的位数，越小越相似
+func hashDistance(x, y uint64) int {
+	return bits.OnesCount64(x ^ y)
+}
+
+// pagePerceptualHash 解码页面并计算感知哈希
+func (a *App) pagePerceptualHash(pagePath string) (string, error) {
+	img, err := a.decodeCoverImage(pagePath)
+	if err != nil {
+		return "", err
+	}
+	return formatHash(dHash(img)), nil
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// UpdateReadingProgress 更新漫画的阅读进度，读到最后一页时自动标记为已读
+func (a *App) UpdateReadingProgress(comicID int64, page int, pageCount int) error {
+	if a.db == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+
+	isRead := pageCount > 0 && page >= pageCount-1
+
+	query := `
+	UPDATE comics
+	SET last_page = ?, page_count = ?, is_read = CASE WHEN ? THEN 1 ELSE is_read END, last_read_at = ?
+	WHERE id = ?`
+
+	_, err := a.db.Exec(query, page, pageCount, isRead, time.Now(), comicID)
+	if err != nil {
+		return fmt.Errorf("更新阅读进度失败: %v", err)
+	}
+
+	return nil
+}
+
+// SetComicRead 手动标记漫画为已读或未读
+func (a *App) SetComicRead(comicID int64, read bool) error {
+	if a.db == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+
+	_, err := a.db.Exec(`UPDATE comics SET is_read = ? WHERE id = ?`, read, comicID)
+	if err != nil {
+		return fmt.Errorf("更新已读状态失败: %v", err)
+	}
+
+	return nil
+}
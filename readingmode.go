@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// 阅读模式
+const (
+	readingModeLTR      = "ltr"
+	readingModeRTL      = "rtl"
+	readingModeVertical = "vertical"
+	readingModeWebtoon  = "webtoon"
+)
+
+// webtoonAspectRatio 页面高宽比中位数超过该值时视为条漫
+const webtoonAspectRatio = 2.5
+
+// validReadingModes 允许保存的阅读模式，空字符串表示跟随上级设置
+var validReadingModes = map[string]bool{
+	"":                  true,
+	readingModeLTR:      true,
+	readingModeRTL:      true,
+	readingModeVertical: true,
+	readingModeWebtoon:  true,
+}
+
+// createReadingModeColumns 为漫画表和系列表添加阅读模式字段
+func (a *App) createReadingModeColumns() error {
+	if err := a.ensureColumn("comics", "reading_mode", "TEXT DEFAULT ''"); err != nil {
+		return err
+	}
+	return a.ensureColumn("series", "reading_mode", "TEXT DEFAULT ''")
+}
+
+// detectReadingMode 根据ComicInfo.xml的Manga字段或页面高宽比推断阅读模式
+func (a *App) detectReadingMode(filePath, fileType string, pages []comicPage) (string, string) {
+	if fileType == "zip" {
+		comicInfo, err := a.readComicInfoFromZip(filePath)
+		if err == nil && comicInfo != nil && strings.EqualFold(comicInfo.Manga, "YesAndRightToLeft") {
+			return readingModeRTL, "comicinfo"
+		}
+	}
+
+	var ratios []float64
+	for _, page := range pages {
+		if page.Width > 0 && page.Height > 0 {
+			ratios = append(ratios, float64(page.Height)/float64(page.Width))
+		}
+	}
+	if len(ratios) > 0 {
+		sort.Float64s(ratios)
+		if ratios[len(ratios)/2] >= webtoonAspectRatio {
+			return readingModeWebtoon, "aspect"
+		}
+	}
+
+	return readingModeLTR, "default"
+}
+
+// resolveReadingMode 依次使用漫画设置、系列设置和自动检测的结果
+func (a *App) resolveReadingMode(comicID int64, pages []comicPage) (string, string, error) {
+	if a.db == nil {
+		return "", "", fmt.Errorf("数据库未初始化")
+	}
+
+	var filePath, fileType, comicMode, seriesMode string
+	err := a.db.QueryRow(`
+	SELECT c.file_path, c.file_type, COALESCE(c.reading_mode, ''), COALESCE(s.reading_mode, '')
+	FROM comics c
+	LEFT JOIN series s ON s.id = c.series_id
+	WHERE c.id = ?`, comicID).Scan(&filePath, &fileType, &comicMode, &seriesMode)
+	if err != nil {
+		return "", "", fmt.Errorf("查询漫画信息失败: %v", err)
+	}
+
+	if comicMode != "" {
+		return comicMode, "comic", nil
+	}
+	if seriesMode != "" {
+		return seriesMode, "series", nil
+	}
+
+	mode, source := a.detectReadingMode(filePath, fileType, pages)
+	return mode, source, nil
+}
+
+// SetComicReadingMode 设置单本漫画的阅读模式，传空字符串恢复跟随系列或自动检测
+func (a *App) SetComicReadingMode(comicID int64, mode string) error {
+	if a.db == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+	if !validReadingModes[mode] {
+		return fmt.Errorf("无效的阅读模式: %s", mode)
+	}
+
+	_, err := a.db.Exec(`UPDATE comics SET reading_mode = ? WHERE id = ?`, mode, comicID)
+	if err != nil {
+		return fmt.Errorf("更新阅读模式失败: %v", err)
+	}
+
+	return nil
+}
+
+// SetSeriesReadingMode 设置整个系列的阅读模式，传空字符串恢复自动检测
+func (a *App) SetSeriesReadingMode(seriesID int64, mode string) error {
+	if a.db == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+	if !validReadingModes[mode] {
+		return fmt.Errorf("无效的阅读模式: %s", mode)
+	}
+
+	_, err := a.db.Exec(`UPDATE series SET reading_mode = ? WHERE id = ?`, mode, seriesID)
+	if err != nil {
+		return fmt.Errorf("更新系列阅读模式失败: %v", err)
+	}
+
+	return nil
+}
+
+// GetReaderData 获取阅读器所需的页面列表、阅读模式及其来源和上次阅读位置
+func (a *App) GetReaderData(comicID int64) (map[string]interface{}, error) {
+	pages, err := a.loadComicPages(comicID)
+	if err != nil {
+		return nil, err
+	}
+
+	mode, source, err := a.resolveReadingMode(comicID, pages)
+	if err != nil {
+		return nil, err
+	}
+
+	var lastPage int
+	err = a.db.QueryRow(`SELECT COALESCE(last_page, 0) FROM comics WHERE id = ?`, comicID).Scan(&lastPage)
+	if err != nil {
+		return nil, fmt.Errorf("查询阅读进度失败: %v", err)
+	}
+
+	return map[string]interface{}{
+		"comicId":           comicID,
+		"pages":             pagesToMaps(pages),
+		"readingMode":       mode,
+		"readingModeSource": source,
+		"lastPage":          lastPage,
+	}, nil
+}
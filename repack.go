@@ -0,0 +1,495 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// RepackOptions 重新压缩漫画的选项
+type RepackOptions struct {
+	Format          string `json:"format"`          // 输出格式：webp、jpeg、png，为空时保持原格式
+	Quality         int    `json:"quality"`         // 有损编码的质量，0使用默认值
+	Lossless        bool   `json:"lossless"`        // webp使用无损编码
+	MaxDimension    int    `json:"maxDimension"`    // 图片长边的上限，0为不限制
+	RecompressLossy bool   `json:"recompressLossy"` // 同时重新编码JPEG、WebP等有损格式的页面，默认只在需要缩小时处理
+	KeepBackup      bool   `json:"keepBackup"`      // 替换后把原文件保留为 .bak
+}
+
+// repackFormats 支持的输出格式及其扩展名
+var repackFormats = map[string]string{
+	"webp": ".webp",
+	"jpeg": ".jpg",
+	"png":  ".png",
+}
+
+// losslessSourceFormats 无损格式的页面总是重新编码，其余格式只在缩小或指定重新编码有损页面时处理
+var losslessSourceFormats = map[string]bool{
+	"png":  true,
+	"bmp":  true,
+	"tiff": true,
+}
+
+// externalWebPEncoders 可用的WebP编码工具，标准库只能解码WebP
+// {in}、{out}、{quality}、{lossless} 分别替换为输入PNG、输出文件、质量和是否无损
+var externalWebPEncoders = [][]string{
+	{"cwebp", "-quiet", "-mt", "-q", "{quality}", "{lossless}", "{in}", "-o", "{out}"},
+	{"magick", "{in}", "-quality", "{quality}", "-define", "webp:lossless={lossless}", "{out}"},
+}
+
+// validateRepackOptions 检查选项并补全默认值
+func validateRepackOptions(options RepackOptions) (RepackOptions, error) {
+	options.Format = strings.ToLower(strings.TrimSpace(options.Format))
+	if options.Format == "jpg" {
+		options.Format = "jpeg"
+	}
+	if _, ok := repackFormats[options.Format]; options.Format != "" && !ok {
+		return options, fmt.Errorf("不支持的输出格式: %s", options.Format)
+	}
+	if options.Format == "" && options.MaxDimension <= 0 {
+		return options, fmt.Errorf("请指定输出格式或最大尺寸")
+	}
+	if options.Quality <= 0 || options.Quality > 100 {
+		options.Quality = defaultJPEGQuality
+	}
+
+	if options.Format == "webp" && !webpEncoderAvailable() {
+		return options, fmt.Errorf("没有可用的webp编码工具，请安装: cwebp / magick")
+	}
+
+	return options, nil
+}
+
+// webpEncoderAvailable 判断是否安装了WebP编码工具
+func webpEncoderAvailable() bool {
+	for _, command := range externalWebPEncoders {
+		if _, err := exec.LookPath(command[0]); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// encodeWebPWithExternalTool 将图片写为临时PNG，调用外部工具编码为WebP
+func encodeWebPWithExternalTool(img image.Image, quality int, lossless bool) ([]byte, error) {
+	tmpDir, err := os.MkdirTemp("", "r-comic-encode-*")
+	if err != nil {
+		return nil, fmt.Errorf("创建临时目录失败: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	inPath := filepath.Join(tmpDir, "in.png")
+	outPath := filepath.Join(tmpDir, "out.webp")
+
+	in, err := os.Create(inPath)
+	if err != nil {
+		return nil, fmt.Errorf("创建临时文件失败: %v", err)
+	}
+	err = (&png.Encoder{CompressionLevel: png.BestSpeed}).Encode(in, img)
+	in.Close()
+	if err != nil {
+		return nil, fmt.Errorf("写入临时文件失败: %v", err)
+	}
+
+	for _, command := range externalWebPEncoders {
+		binary, err := exec.LookPath(command[0])
+		if err != nil {
+			continue
+		}
+
+		args := make([]string, 0, len(command)-1)
+		for _, arg := range command[1:] {
+			switch arg {
+			case "{lossless}":
+				// cwebp的无损开关是单独的参数
+				if lossless {
+					args = append(args, "-lossless")
+				}
+				continue
+			}
+			arg = strings.ReplaceAll(arg, "{in}", inPath)
+			arg = strings.ReplaceAll(arg, "{out}", outPath)
+			arg = strings.ReplaceAll(arg, "{quality}", strconv.Itoa(quality))
+			arg = strings.ReplaceAll(arg, "{lossless}", strconv.FormatBool(lossless))
+			args = append(args, arg)
+		}
+
+		output, err := exec.Command(binary, args...).CombinedOutput()
+		if err != nil {
+			fmt.Printf("%s 编码失败: %v %s\n", command[0], err, strings.TrimSpace(string(output)))
+			continue
+		}
+		if data, err := os.ReadFile(outPath); err == nil {
+			return data, nil
+		}
+	}
+
+	return nil, fmt.Errorf("没有可用的webp编码工具，请安装: cwebp / magick")
+}
+
+// encodeRepackedImage 按输出格式编码图片
+func encodeRepackedImage(img image.Image, format string, options RepackOptions) ([]byte, error) {
+	var buf bytes.Buffer
+	switch format {
+	case "webp":
+		return encodeWebPWithExternalTool(img, options.Quality, options.Lossless)
+	case "png":
+		if err := (&png.Encoder{CompressionLevel: png.BestCompression}).Encode(&buf, img); err != nil {
+			return nil, fmt.Errorf("编码PNG失败: %v", err)
+		}
+	default:
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: options.Quality}); err != nil {
+			return nil, fmt.Errorf("编码JPEG失败: %v", err)
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// repackedEntry 一个条目重新压缩后的结果，data为空时原样复制
+type repackedEntry struct {
+	name string
+	data []byte
+	err  error
+}
+
+// repackEntry 重新编码一个图片条目；格式不需要处理或结果没有变小时返回空数据
+func (a *App) repackEntry(file *zip.File, options RepackOptions) repackedEntry {
+	rc, err := file.Open()
+	if err != nil {
+		return repackedEntry{err: fmt.Errorf("打开条目 %s 失败: %v", file.Name, err)}
+	}
+	original, err := io.ReadAll(rc)
+	rc.Close()
+	if err != nil {
+		return repackedEntry{err: fmt.Errorf("读取条目 %s 失败: %v", file.Name, err)}
+	}
+
+	config, sourceFormat, err := image.DecodeConfig(bytes.NewReader(original))
+	if err != nil {
+		return repackedEntry{}
+	}
+	oversized := options.MaxDimension > 0 && (config.Width > options.MaxDimension || config.Height > options.MaxDimension)
+	if _, ok := repackFormats[sourceFormat]; !ok && !losslessSourceFormats[sourceFormat] {
+		// GIF动画、AVIF等格式保持原样
+		return repackedEntry{}
+	}
+	if !losslessSourceFormats[sourceFormat] && !options.RecompressLossy && !oversized {
+		return repackedEntry{}
+	}
+
+	format := options.Format
+	if format == "" {
+		format = sourceFormat
+		if losslessSourceFormats[sourceFormat] {
+			format = "png"
+		}
+	}
+	if format == "webp" && !webpEncoderAvailable() {
+		// 保持原格式缩小WebP页面需要编码工具，没有时保留原图
+		return repackedEntry{}
+	}
+
+	var data []byte
+	err = a.imagePool.Do(func() error {
+		img, _, err := image.Decode(bytes.NewReader(original))
+		if err != nil {
+			return err
+		}
+		if oversized {
+			img = resizeToFit(img, options.MaxDimension, options.MaxDimension)
+		}
+		data, err = encodeRepackedImage(img, format, options)
+		return err
+	})
+	if err != nil {
+		return repackedEntry{err: fmt.Errorf("重新编码 %s 失败: %v", file.Name, err)}
+	}
+
+	// 没有缩小尺寸且体积没有变小时保留原图
+	if !oversized && len(data) >= len(original) {
+		return repackedEntry{}
+	}
+
+	name := strings.TrimSuffix(file.Name, path.Ext(file.Name)) + repackFormats[format]
+	return repackedEntry{name: name, data: data}
+}
+
+// repackComic 重新压缩一本漫画：写入临时文件并校验通过后才替换原文件
+func (a *App) repackComic(ctx context.Context, comicID int64, options RepackOptions) (map[string]interface{}, error) {
+	unlock, err := a.lockComic(comicID)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	filePath, fileType, err := a.getComicPath(comicID)
+	if err != nil {
+		return nil, err
+	}
+	if fileType != "zip" {
+		return nil, fmt.Errorf("仅支持重新压缩zip/cbz文件: %s", filePath)
+	}
+	// 已有的备份可能是唯一的原始文件，不能被再次重新压缩的备份覆盖
+	backupPath := filePath + ".bak"
+	if options.KeepBackup {
+		if _, err := os.Stat(backupPath); err == nil {
+			return nil, fmt.Errorf("备份文件已存在: %s", backupPath)
+		}
+	}
+
+	fileInfo, err := os.Stat(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("获取文件信息失败: %v", err)
+	}
+	originalPages, err := a.listZipPages(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	reader, err := zip.OpenReader(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("打开zip文件失败: %v", err)
+	}
+	defer reader.Close()
+
+	tmpFile, err := os.CreateTemp(filepath.Dir(filePath), "."+filepath.Base(filePath)+".*.tmp")
+	if err != nil {
+		return nil, fmt.Errorf("创建临时文件失败: %v", err)
+	}
+	tmpPath := tmpFile.Name()
+	committed := false
+	defer func() {
+		if !committed {
+			tmpFile.Close()
+			os.Remove(tmpPath)
+		}
+	}()
+
+	writer := zip.NewWriter(tmpFile)
+	if err := writer.SetComment(reader.Comment); err != nil {
+		return nil, fmt.Errorf("写入zip注释失败: %v", err)
+	}
+
+	names := make(map[string]bool, len(reader.File))
+	for _, file := range reader.File {
+		names[file.Name] = true
+	}
+
+	// 每批并发处理若干条目，再按原顺序写入
+	matcher := a.ignoreMatcherFor(filePath)
+	recompressed, kept := 0, 0
+	// 内层压缩包原样复制，其中的页面不会重新压缩，在结果中列出
+	skippedArchives := []string{}
+	batchSize := runtime.NumCPU()
+	for start := 0; start < len(reader.File); start += batchSize {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		batch := reader.File[start:min(start+batchSize, len(reader.File))]
+		entries := make([]repackedEntry, len(batch))
+		var wg sync.WaitGroup
+		for i, file := range batch {
+			if isArchiveEntry(file.Name) && !file.FileInfo().IsDir() && !matcher.ignored(file.Name) {
+				skippedArchives = append(skippedArchives, file.Name)
+			}
+			if file.FileInfo().IsDir() || matcher.ignored(file.Name) || isArchiveEntry(file.Name) || isComicInfoEntry(file.Name) {
+				continue
+			}
+			wg.Add(1)
+			go func(i int, file *zip.File) {
+				defer wg.Done()
+				entries[i] = a.repackEntry(file, options)
+			}(i, file)
+		}
+		wg.Wait()
+
+		for i, file := range batch {
+			entry := entries[i]
+			if entry.err != nil {
+				return nil, entry.err
+			}
+			// 改扩展名后与已有条目重名时保留原图
+			if entry.data != nil && entry.name != file.Name && names[entry.name] {
+				entry.data = nil
+			}
+			if entry.data == nil {
+				if err := writer.Copy(file); err != nil {
+					return nil, fmt.Errorf("复制条目 %s 失败: %v", file.Name, err)
+				}
+				if !file.FileInfo().IsDir() {
+					kept++
+				}
+				continue
+			}
+
+			names[entry.name] = true
+			w, err := writer.CreateHeader(&zip.FileHeader{Name: entry.name, Method: zip.Store, Modified: file.Modified})
+			if err == nil {
+				_, err = w.Write(entry.data)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("写入条目 %s 失败: %v", entry.name, err)
+			}
+			recompressed++
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("写入zip文件失败: %v", err)
+	}
+	if err := tmpFile.Sync(); err != nil {
+		return nil, fmt.Errorf("同步临时文件失败: %v", err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		return nil, fmt.Errorf("关闭临时文件失败: %v", err)
+	}
+
+	result := map[string]interface{}{
+		"comicId":           comicID,
+		"filePath":          filePath,
+		"originalSize":      fileInfo.Size(),
+		"newSize":           fileInfo.Size(),
+		"savedBytes":        int64(0),
+		"recompressedPages": recompressed,
+		"keptEntries":       kept,
+		"skippedArchives":   skippedArchives,
+		"replaced":          false,
+	}
+	if recompressed == 0 {
+		return result, nil
+	}
+
+	// 校验新文件：所有条目可读、页面头部可解析，且页数不变
+	if status, problems := a.verifyPath(tmpPath, "zip"); status != integrityOK {
+		return nil, fmt.Errorf("重新压缩的结果校验失败: %s", problems[0].Reason)
+	}
+	newPages, err := a.listZipPages(tmpPath)
+	if err != nil {
+		return nil, err
+	}
+	if len(newPages) != len(originalPages) {
+		return nil, fmt.Errorf("重新压缩后页数不一致: %d -> %d", len(originalPages), len(newPages))
+	}
+
+	newInfo, err := os.Stat(tmpPath)
+	if err != nil {
+		return nil, fmt.Errorf("获取文件信息失败: %v", err)
+	}
+	if err := os.Chmod(tmpPath, fileInfo.Mode().Perm()); err != nil {
+		return nil, fmt.Errorf("设置文件权限失败: %v", err)
+	}
+
+	// Windows下必须先关闭原文件才能替换
+	reader.Close()
+	// 原文件先移到备份位置，更新书库记录失败时还能换回来；不保留备份时成功后再删除
+	if !options.KeepBackup {
+		backupFile, err := os.CreateTemp(filepath.Dir(filePath), "."+filepath.Base(filePath)+".*.orig")
+		if err != nil {
+			return nil, fmt.Errorf("创建备份文件失败: %v", err)
+		}
+		backupFile.Close()
+		backupPath = backupFile.Name()
+	} else if _, err := os.Stat(backupPath); err == nil {
+		return nil, fmt.Errorf("备份文件已存在: %s", backupPath)
+	}
+	if err := os.Rename(filePath, backupPath); err != nil {
+		if !options.KeepBackup {
+			os.Remove(backupPath)
+		}
+		return nil, fmt.Errorf("备份原文件失败: %v", err)
+	}
+	if err := os.Rename(tmpPath, filePath); err != nil {
+		// 恢复备份，保证书库记录指向的文件仍然存在
+		if restoreErr := os.Rename(backupPath, filePath); restoreErr != nil {
+			return nil, fmt.Errorf("替换原文件失败: %v，恢复备份也失败: %v", err, restoreErr)
+		}
+		return nil, fmt.Errorf("替换原文件失败: %v", err)
+	}
+	committed = true
+
+	// 条目改了扩展名，页面索引和封面必须跟着更新，失败时换回原文件并按原文件重建索引
+	if err := a.replaceComicSource(comicID, filePath, newInfo.Size()); err != nil {
+		if restoreErr := os.Rename(backupPath, filePath); restoreErr != nil {
+			return nil, fmt.Errorf("更新书库记录失败: %v，恢复原文件也失败: %v", err, restoreErr)
+		}
+		if restoreErr := a.replaceComicSource(comicID, filePath, fileInfo.Size()); restoreErr != nil {
+			return nil, fmt.Errorf("更新书库记录失败: %v，已恢复原文件但重建索引失败: %v", err, restoreErr)
+		}
+		return nil, fmt.Errorf("更新书库记录失败，已恢复原文件: %v", err)
+	}
+	if !options.KeepBackup {
+		if err := os.Remove(backupPath); err != nil {
+			fmt.Printf("删除临时备份失败 %s: %v\n", backupPath, err)
+		}
+	}
+
+	result["newSize"] = newInfo.Size()
+	result["savedBytes"] = fileInfo.Size() - newInfo.Size()
+	result["replaced"] = true
+	fmt.Printf("已重新压缩 %s: %d -> %d 字节\n", filePath, fileInfo.Size(), newInfo.Size())
+	return result, nil
+}
+
+// StartRepack 在后台重新压缩漫画的页面以节省空间，comicIDs为空时处理书库中所有zip/cbz漫画
+// 返回任务ID，通过 GetJob 查看每本漫画节省的空间
+func (a *App) StartRepack(comicIDs []int64, options RepackOptions) (string, error) {
+	if a.db == nil {
+		return "", fmt.Errorf("数据库未初始化")
+	}
+
+	options, err := validateRepackOptions(options)
+	if err != nil {
+		return "", err
+	}
+
+	if len(comicIDs) == 0 {
+		rows, err := a.db.Query(`SELECT id FROM comics WHERE file_type = 'zip' ORDER BY id`)
+		if err != nil {
+			return "", fmt.Errorf("查询漫画失败: %v", err)
+		}
+		for rows.Next() {
+			var id int64
+			if err := rows.Scan(&id); err == nil {
+				comicIDs = append(comicIDs, id)
+			}
+		}
+		rows.Close()
+	}
+
+	return a.startJob("repack", len(comicIDs), func(ctx context.Context, job *backgroundJob) error {
+		for _, comicID := range comicIDs {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			result, err := a.repackComic(ctx, comicID, options)
+			if err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				fmt.Printf("重新压缩漫画失败 %d: %v\n", comicID, err)
+				result = map[string]interface{}{
+					"comicId":  comicID,
+					"replaced": false,
+					"error":    err.Error(),
+				}
+			}
+			job.advance(fmt.Sprintf("已处理漫画 %d", comicID), result)
+		}
+		return nil
+	}), nil
+}
@@ -0,0 +1,315 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// seriesInfo 漫画所属系列及其卷号、话数
+type seriesInfo struct {
+	Series string
+	Volume string
+	Number string
+}
+
+// createSeriesTables 创建系列表并为漫画表添加系列字段
+func (a *App) createSeriesTables() error {
+	createSeriesTable := `
+	CREATE TABLE IF NOT EXISTS series (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL,
+		name_key TEXT UNIQUE NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);`
+
+	_, err := a.db.Exec(createSeriesTable)
+	if err != nil {
+		return fmt.Errorf("创建series表失败: %v", err)
+	}
+
+	seriesColumns := [][2]string{
+		{"series_id", "INTEGER REFERENCES series (id)"},
+		{"series_volume", "TEXT"},
+		{"series_number", "TEXT"},
+	}
+	for _, column := range seriesColumns {
+		if err := a.ensureColumn("comics", column[0], column[1]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// seriesNameKey 生成系列名的归一化键，忽略大小写和多余空白
+func seriesNameKey(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+// cleanSeriesTitle 去掉系列名首尾的分隔符
+func cleanSeriesTitle(title string) string {
+	return strings.Trim(title, " _-.·~")
+}
+
+// detectSeries 优先使用ComicInfo.xml中的系列信息，否则从文件名解析
+func (a *App) detectSeries(filePath, fileType string) seriesInfo {
+	if fileType == "zip" {
+		comicInfo, err := a.readComicInfoFromZip(filePath)
+		if err != nil {
+			fmt.Printf("读取ComicInfo.xml失败 %s: %v\n", filePath, err)
+		} else if comicInfo != nil && strings.TrimSpace(comicInfo.Series) != "" {
+			info := seriesInfo{
+				Series: strings.TrimSpace(comicInfo.Series),
+				Number: strings.TrimSpace(comicInfo.Number),
+			}
+			if comicInfo.Volume > 0 {
+				info.Volume = strconv.Itoa(comicInfo.Volume)
+			}
+			return info
+		}
+	}
+
+	// 没有ComicInfo.xml时使用文件名解析结果
+	parsed := a.parseFileName(filePath)
+	return seriesInfo{Series: parsed.Series, Volume: parsed.Volume, Number: parsed.Chapter}
+}
+
+// getOrCreateSeries 按归一化名称查找系列，不存在时创建
+func (a *App) getOrCreateSeries(name string) (int64, error) {
+	key := seriesNameKey(name)
+
+	var seriesID int64
+	err := a.db.QueryRow(`SELECT id FROM series WHERE name_key = ?`, key).Scan(&seriesID)
+	if err == nil {
+		return seriesID, nil
+	}
+	if err != sql.ErrNoRows {
+		return 0, fmt.Errorf("查询系列失败: %v", err)
+	}
+
+	result, err := a.db.Exec(`INSERT INTO series (name, name_key) VALUES (?, ?)`, name, key)
+	if err != nil {
+		return 0, fmt.Errorf("创建系列失败: %v", err)
+	}
+
+	return result.LastInsertId()
+}
+
+// assignComicToSeries 解析漫画所属系列并写入数据库
+func (a *App) assignComicToSeries(comicID int64, filePath, fileType string) error {
+	info := a.detectSeries(filePath, fileType)
+	if info.Series == "" {
+		return nil
+	}
+
+	seriesID, err := a.getOrCreateSeries(info.Series)
+	if err != nil {
+		return err
+	}
+
+	_, err = a.db.Exec(`UPDATE comics SET series_id = ?, series_volume = ?, series_number = ? WHERE id = ?`,
+		seriesID, info.Volume, info.Number, comicID)
+	if err != nil {
+		return fmt.Errorf("更新漫画系列失败: %v", err)
+	}
+
+	_, err = a.db.Exec(`UPDATE series SET updated_at = ? WHERE id = ?`, time.Now(), seriesID)
+	if err != nil {
+		return fmt.Errorf("更新系列失败: %v", err)
+	}
+
+	return nil
+}
+
+// indexComicByPath 为刚导入的漫画保存文件名解析结果、分配系列并建立页面索引
+func (a *App) indexComicByPath(filePath string) error {
+	var comicID int64
+	var fileType string
+	err := a.db.QueryRow(`SELECT id, file_type FROM comics WHERE file_path = ?`, filePath).Scan(&comicID, &fileType)
+	if err != nil {
+		return fmt.Errorf("查询漫画信息失败: %v", err)
+	}
+
+	if err := a.saveParsedFileName(comicID, filePath); err != nil {
+		return err
+	}
+
+	if err := a.assignComicToSeries(comicID, filePath, fileType); err != nil {
+		return err
+	}
+
+	if err := a.indexComicPages(comicID); err != nil {
+		return err
+	}
+
+	return a.refreshCover(comicID)
+}
+
+// removeEmptySeries 删除已经没有漫画的系列
+func (a *App) removeEmptySeries() error {
+	_, err := a.db.Exec(`DELETE FROM series WHERE id NOT IN (SELECT DISTINCT series_id FROM comics WHERE series_id IS NOT NULL)`)
+	if err != nil {
+		return fmt.Errorf("清理空系列失败: %v", err)
+	}
+	return nil
+}
+
+// RegroupSeries 重新解析所有漫画的文件名并分配系列
+func (a *App) RegroupSeries() error {
+	if a.db == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+
+	rows, err := a.db.Query(`SELECT id, file_path, file_type FROM comics`)
+	if err != nil {
+		return fmt.Errorf("查询漫画信息失败: %v", err)
+	}
+
+	type comicRef struct {
+		id       int64
+		filePath string
+		fileType string
+	}
+	var comics []comicRef
+	for rows.Next() {
+		var ref comicRef
+		if err := rows.Scan(&ref.id, &ref.filePath, &ref.fileType); err != nil {
+			continue
+		}
+		comics = append(comics, ref)
+	}
+	rows.Close()
+
+	for _, comic := range comics {
+		if err := a.saveParsedFileName(comic.id, comic.filePath); err != nil {
+			fmt.Printf("解析文件名失败 %s: %v\n", comic.filePath, err)
+		}
+		if err := a.assignComicToSeries(comic.id, comic.filePath, comic.fileType); err != nil {
+			fmt.Printf("分配系列失败 %s: %v\n", comic.filePath, err)
+		}
+	}
+
+	return a.removeEmptySeries()
+}
+
+// GetSeriesList 获取所有系列及其漫画数量、未读数量
+func (a *App) GetSeriesList() ([]map[string]interface{}, error) {
+	if a.db == nil {
+		return nil, fmt.Errorf("数据库未初始化")
+	}
+
+	query := `
+	SELECT s.id, s.name, COUNT(c.id), COALESCE(SUM(CASE WHEN c.is_read = 0 THEN 1 ELSE 0 END), 0), s.updated_at
+	FROM series s
+	JOIN comics c ON c.series_id = s.id
+	GROUP BY s.id
+	ORDER BY s.updated_at DESC`
+
+	rows, err := a.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("查询系列失败: %v", err)
+	}
+	defer rows.Close()
+
+	var seriesList []map[string]interface{}
+	for rows.Next() {
+		var id, comicCount, unreadCount int64
+		var name, updatedAt string
+
+		if err := rows.Scan(&id, &name, &comicCount, &unreadCount, &updatedAt); err != nil {
+			continue
+		}
+
+		seriesList = append(seriesList, map[string]interface{}{
+			"id":          id,
+			"name":        name,
+			"comicCount":  comicCount,
+			"unreadCount": unreadCount,
+			"updatedAt":   updatedAt,
+		})
+	}
+
+	// 封面取系列中排序后的第一本
+	firstComics, err := a.firstComicsOfSeries()
+	if err != nil {
+		return nil, err
+	}
+	for _, series := range seriesList {
+		if comic, ok := firstComics[series["id"].(int64)]; ok {
+			series["firstImage"] = comic["firstImage"]
+		}
+	}
+
+	return seriesList, nil
+}
+
+// firstComicsOfSeries 一次查询出每个系列排序后的第一本漫画
+func (a *App) firstComicsOfSeries() (map[int64]map[string]interface{}, error) {
+	rows, err := a.db.Query(`SELECT series_id, title, COALESCE(first_image, ''), COALESCE(series_volume, ''), COALESCE(series_number, '')
+		FROM comics WHERE series_id IS NOT NULL`)
+	if err != nil {
+		return nil, fmt.Errorf("查询系列漫画失败: %v", err)
+	}
+	defer rows.Close()
+
+	firstComics := make(map[int64]map[string]interface{})
+	for rows.Next() {
+		var seriesID int64
+		var title, firstImage, volume, number string
+		if err := rows.Scan(&seriesID, &title, &firstImage, &volume, &number); err != nil {
+			continue
+		}
+
+		comic := map[string]interface{}{
+			"title":      title,
+			"firstImage": firstImage,
+			"volume":     volume,
+			"number":     number,
+		}
+		if first, ok := firstComics[seriesID]; !ok || a.compareSeriesIssues(comic, first) {
+			firstComics[seriesID] = comic
+		}
+	}
+
+	return firstComics, nil
+}
+
+// GetSeriesComics 获取系列中的所有漫画，按卷号、话数自然排序
+func (a *App) GetSeriesComics(seriesID int64) ([]map[string]interface{}, error) {
+	if a.db == nil {
+		return nil, fmt.Errorf("数据库未初始化")
+	}
+
+	comics, err := a.queryComics(`SELECT `+comicSelectColumns+` FROM comics c WHERE c.series_id = ?`, seriesID)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(comics, func(i, j int) bool {
+		return a.compareSeriesIssues(comics[i], comics[j])
+	})
+
+	return comics, nil
+}
+
+// compareSeriesIssues 依次按卷号、话数、标题自然排序
+func (a *App) compareSeriesIssues(x, y map[string]interface{}) bool {
+	for _, key := range []string{"volume", "number", "title"} {
+		xs, _ := x[key].(string)
+		ys, _ := y[key].(string)
+		if xs == ys {
+			continue
+		}
+		// 没有卷号或话数的排在后面
+		if xs == "" || ys == "" {
+			return ys == ""
+		}
+		return a.compareNatural(xs, ys)
+	}
+	return false
+}
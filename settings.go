@@ -0,0 +1,67 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// createSettingsTables 创建键值形式的配置表，值以JSON保存
+func (a *App) createSettingsTables() error {
+	createSettingsTable := `
+	CREATE TABLE IF NOT EXISTS settings (
+		key TEXT PRIMARY KEY,
+		value TEXT NOT NULL,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);`
+
+	_, err := a.db.Exec(createSettingsTable)
+	if err != nil {
+		return fmt.Errorf("创建settings表失败: %v", err)
+	}
+
+	return nil
+}
+
+// loadSetting 读取配置并解码到value中，配置不存在时返回false
+func (a *App) loadSetting(key string, value interface{}) (bool, error) {
+	if a.db == nil {
+		return false, fmt.Errorf("数据库未初始化")
+	}
+
+	var data string
+	err := a.db.QueryRow(`SELECT value FROM settings WHERE key = ?`, key).Scan(&data)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("读取配置 %s 失败: %v", key, err)
+	}
+
+	if err := json.Unmarshal([]byte(data), value); err != nil {
+		return false, fmt.Errorf("解析配置 %s 失败: %v", key, err)
+	}
+
+	return true, nil
+}
+
+// saveSetting 以JSON形式保存配置
+func (a *App) saveSetting(key string, value interface{}) error {
+	if a.db == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("序列化配置 %s 失败: %v", key, err)
+	}
+
+	_, err = a.db.Exec(`INSERT OR REPLACE INTO settings (key, value, updated_at) VALUES (?, ?, ?)`,
+		key, string(data), time.Now())
+	if err != nil {
+		return fmt.Errorf("保存配置 %s 失败: %v", key, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+)
+
+// spreadAspectRatio 宽高比达到该值的页面视为跨页
+// 单页约为0.7，两页拼成的跨页约为1.4；扫描时多出的白边或略宽的单页可能稍宽于正方形，
+// 取1.2避免把这类单页当作跨页单独显示、切分或旋转
+const spreadAspectRatio = 1.2
+
+// isSpreadPage 判断页面是否为横向跨页，尺寸未知时按单页处理
+func isSpreadPage(page comicPage) bool {
+	if page.Width <= 0 || page.Height <= 0 {
+		return false
+	}
+	return float64(page.Width)/float64(page.Height) >= spreadAspectRatio
+}
+
+// computeTwoPageLayout 计算双页布局
+// 前coverOffset页单独显示；跨页单独显示；跨页前落单的页面也单独显示，之后重新开始配对
+// 返回的每组页码按屏幕从左到右的显示顺序排列，右到左阅读时组内顺序相反
+func computeTwoPageLayout(pages []comicPage, coverOffset int, rightToLeft bool) []map[string]interface{} {
+	var layout []map[string]interface{}
+
+	addGroup := func(indexes []int, spread bool) {
+		if rightToLeft && len(indexes) == 2 {
+			indexes = []int{indexes[1], indexes[0]}
+		}
+		layout = append(layout, map[string]interface{}{
+			"pages":  indexes,
+			"spread": spread,
+		})
+	}
+
+	pending := -1
+	for i, page := range pages {
+		if i < coverOffset {
+			addGroup([]int{page.Index}, isSpreadPage(page))
+			continue
+		}
+
+		if isSpreadPage(page) {
+			if pending >= 0 {
+				addGroup([]int{pending}, false)
+				pending = -1
+			}
+			addGroup([]int{page.Index}, true)
+			continue
+		}
+
+		if pending < 0 {
+			pending = page.Index
+			continue
+		}
+		addGroup([]int{pending, page.Index}, false)
+		pending = -1
+	}
+
+	if pending >= 0 {
+		addGroup([]int{pending}, false)
+	}
+
+	return layout
+}
+
+// GetTwoPageLayout 获取漫画的双页阅读布局，coverOffset为开头单独显示的页数
+// 条漫和纵向阅读模式不做配对，每页单独一组
+func (a *App) GetTwoPageLayout(comicID int64, coverOffset int) (map[string]interface{}, error) {
+	if coverOffset < 0 {
+		return nil, fmt.Errorf("无效的封面偏移: %d", coverOffset)
+	}
+
+	pages, err := a.loadComicPages(comicID)
+	if err != nil {
+		return nil, err
+	}
+
+	mode, _, err := a.resolveReadingMode(comicID, pages)
+	if err != nil {
+		return nil, err
+	}
+
+	var layout []map[string]interface{}
+	if mode == readingModeVertical || mode == readingModeWebtoon {
+		for _, page := range pages {
+			layout = append(layout, map[string]interface{}{
+				"pages":  []int{page.Index},
+				"spread": false,
+			})
+		}
+	} else {
+		layout = computeTwoPageLayout(pages, coverOffset, mode == readingModeRTL)
+	}
+
+	return map[string]interface{}{
+		"comicId":     comicID,
+		"readingMode": mode,
+		"coverOffset": coverOffset,
+		"layout":      layout,
+	}, nil
+}
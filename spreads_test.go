@@ -0,0 +1,76 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestIsSpreadPage 宽高比达到spreadAspectRatio才视为跨页
+func TestIsSpreadPage(t *testing.T) {
+	tests := []struct {
+		width, height int
+		want          bool
+	}{
+		{800, 1200, false},
+		{1000, 1000, false},
+		{1100, 1000, false},
+		{1200, 1000, true},
+		{1600, 1200, true},
+		{0, 1200, false},
+		{1600, 0, false},
+	}
+
+	for _, tt := range tests {
+		page := comicPage{Width: tt.width, Height: tt.height}
+		if got := isSpreadPage(page); got != tt.want {
+			t.Errorf("isSpreadPage(%dx%d) = %v, want %v", tt.width, tt.height, got, tt.want)
+		}
+	}
+}
+
+// TestComputeTwoPageLayout 封面单独显示，跨页及跨页前落单的页面单独成组，右到左时组内顺序相反
+func TestComputeTwoPageLayout(t *testing.T) {
+	// 按顺序生成页面，s 表示跨页，p 表示单页
+	makePages := func(kinds string) []comicPage {
+		pages := make([]comicPage, len(kinds))
+		for i, kind := range kinds {
+			pages[i] = comicPage{Index: i, Width: 800, Height: 1200}
+			if kind == 's' {
+				pages[i].Width = 1600
+			}
+		}
+		return pages
+	}
+
+	tests := []struct {
+		name        string
+		kinds       string
+		coverOffset int
+		rightToLeft bool
+		wantPages   [][]int
+		wantSpread  []bool
+	}{
+		{"empty", "", 0, false, nil, nil},
+		{"pairs", "pppp", 0, false, [][]int{{0, 1}, {2, 3}}, []bool{false, false}},
+		{"odd count", "ppp", 0, false, [][]int{{0, 1}, {2}}, []bool{false, false}},
+		{"cover offset", "ppppp", 1, false, [][]int{{0}, {1, 2}, {3, 4}}, []bool{false, false, false}},
+		{"right to left", "ppppp", 1, true, [][]int{{0}, {2, 1}, {4, 3}}, []bool{false, false, false}},
+		{"spread alone", "ppsp", 0, false, [][]int{{0, 1}, {2}, {3}}, []bool{false, true, false}},
+		{"orphan before spread", "pspp", 0, false, [][]int{{0}, {1}, {2, 3}}, []bool{false, true, false}},
+		{"spread cover", "sppp", 1, false, [][]int{{0}, {1, 2}, {3}}, []bool{true, false, false}},
+		{"cover offset beyond pages", "pp", 5, false, [][]int{{0}, {1}}, []bool{false, false}},
+	}
+
+	for _, tt := range tests {
+		layout := computeTwoPageLayout(makePages(tt.kinds), tt.coverOffset, tt.rightToLeft)
+		var gotPages [][]int
+		var gotSpread []bool
+		for _, group := range layout {
+			gotPages = append(gotPages, group["pages"].([]int))
+			gotSpread = append(gotSpread, group["spread"].(bool))
+		}
+		if !reflect.DeepEqual(gotPages, tt.wantPages) || !reflect.DeepEqual(gotSpread, tt.wantSpread) {
+			t.Errorf("%s: layout = %v %v, want %v %v", tt.name, gotPages, gotSpread, tt.wantPages, tt.wantSpread)
+		}
+	}
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// createReadingSessionTables 创建阅读记录表，时间以Unix秒保存便于按天统计
+func (a *App) createReadingSessionTables() error {
+	createSessionTable := `
+	CREATE TABLE IF NOT EXISTS reading_sessions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		comic_id INTEGER NOT NULL,
+		started_at INTEGER NOT NULL,
+		ended_at INTEGER NOT NULL,
+		start_page INTEGER DEFAULT 0,
+		end_page INTEGER DEFAULT 0,
+		pages_turned INTEGER DEFAULT 0,
+		FOREIGN KEY (comic_id) REFERENCES comics (id)
+	);`
+
+	_, err := a.db.Exec(createSessionTable)
+	if err != nil {
+		return fmt.Errorf("创建reading_sessions表失败: %v", err)
+	}
+
+	_, err = a.db.Exec(`CREATE INDEX IF NOT EXISTS idx_reading_sessions_started ON reading_sessions (started_at)`)
+	if err != nil {
+		return fmt.Errorf("创建reading_sessions索引失败: %v", err)
+	}
+
+	return nil
+}
+
+// StartReadingSession 打开漫画时开始一次阅读记录
+func (a *App) StartReadingSession(comicID int64, page int) (int64, error) {
+	if a.db == nil {
+		return 0, fmt.Errorf("数据库未初始化")
+	}
+
+	now := time.Now().Unix()
+	result, err := a.db.Exec(`INSERT INTO reading_sessions (comic_id, started_at, ended_at, start_page, end_page) VALUES (?, ?, ?, ?, ?)`,
+		comicID, now, now, page, page)
+	if err != nil {
+		return 0, fmt.Errorf("创建阅读记录失败: %v", err)
+	}
+
+	return result.LastInsertId()
+}
+
+// RecordPageTurn 翻页时更新阅读记录的结束时间、当前页和翻页数
+func (a *App) RecordPageTurn(sessionID int64, page int) error {
+	if a.db == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+
+	_, err := a.db.Exec(`UPDATE reading_sessions SET ended_at = ?, end_page = ?, pages_turned = pages_turned + 1 WHERE id = ?`,
+		time.Now().Unix(), page, sessionID)
+	if err != nil {
+		return fmt.Errorf("更新阅读记录失败: %v", err)
+	}
+
+	return nil
+}
+
+// EndReadingSession 关闭漫画时结束阅读记录并同步阅读进度
+func (a *App) EndReadingSession(sessionID int64, page int) error {
+	if a.db == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+
+	_, err := a.db.Exec(`UPDATE reading_sessions SET ended_at = ?, end_page = ? WHERE id = ?`, time.Now().Unix(), page, sessionID)
+	if err != nil {
+		return fmt.Errorf("结束阅读记录失败: %v", err)
+	}
+
+	var comicID int64
+	var pageCount int
+	err = a.db.QueryRow(`SELECT c.id, c.page_count FROM reading_sessions s JOIN comics c ON c.id = s.comic_id WHERE s.id = ?`, sessionID).
+		Scan(&comicID, &pageCount)
+	if err != nil {
+		return fmt.Errorf("查询阅读记录失败: %v", err)
+	}
+
+	return a.UpdateReadingProgress(comicID, page, pageCount)
+}
+
+// GetReadingHistory 获取最近的阅读记录
+func (a *App) GetReadingHistory(limit int) ([]map[string]interface{}, error) {
+	if a.db == nil {
+		return nil, fmt.Errorf("数据库未初始化")
+	}
+	if limit <= 0 {
+		limit = 50
+	}
+
+	query := `
+	SELECT s.id, s.comic_id, c.title, COALESCE(c.first_image, ''), s.started_at, s.ended_at, s.start_page, s.end_page, s.pages_turned
+	FROM reading_sessions s
+	JOIN comics c ON c.id = s.comic_id
+	ORDER BY s.started_at DESC
+	LIMIT ?`
+
+	rows, err := a.db.Query(query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("查询阅读记录失败: %v", err)
+	}
+	defer rows.Close()
+
+	var history []map[string]interface{}
+	for rows.Next() {
+		var id, comicID, startedAt, endedAt int64
+		var startPage, endPage, pagesTurned int
+		var title, firstImage string
+
+		if err := rows.Scan(&id, &comicID, &title, &firstImage, &startedAt, &endedAt, &startPage, &endPage, &pagesTurned); err != nil {
+			continue
+		}
+
+		history = append(history, map[string]interface{}{
+			"id":          id,
+			"comicId":     comicID,
+			"title":       title,
+			"firstImage":  firstImage,
+			"startedAt":   time.Unix(startedAt, 0).Format(time.RFC3339),
+			"endedAt":     time.Unix(endedAt, 0).Format(time.RFC3339),
+			"duration":    endedAt - startedAt,
+			"startPage":   startPage,
+			"endPage":     endPage,
+			"pagesTurned": pagesTurned,
+		})
+	}
+
+	return history, nil
+}
+
+// GetReadingStats 统计最近days天的阅读数据：每日页数与时长、总时长、完成率和阅读最多的系列
+func (a *App) GetReadingStats(days int) (map[string]interface{}, error) {
+	if a.db == nil {
+		return nil, fmt.Errorf("数据库未初始化")
+	}
+	if days <= 0 {
+		days = 30
+	}
+
+	since := time.Now().AddDate(0, 0, -days).Unix()
+
+	// 每日统计
+	dailyQuery := `
+	SELECT date(started_at, 'unixepoch', 'localtime') AS day, SUM(pages_turned), SUM(ended_at - started_at), COUNT(*)
+	FROM reading_sessions
+	WHERE started_at >= ?
+	GROUP BY day
+	ORDER BY day`
+
+	rows, err := a.db.Query(dailyQuery, since)
+	if err != nil {
+		return nil, fmt.Errorf("统计每日阅读失败: %v", err)
+	}
+
+	var daily []map[string]interface{}
+	var totalPages, totalSeconds, totalSessions int64
+	for rows.Next() {
+		var day string
+		var pages, seconds, sessions int64
+		if err := rows.Scan(&day, &pages, &seconds, &sessions); err != nil {
+			continue
+		}
+		daily = append(daily, map[string]interface{}{
+			"date":     day,
+			"pages":    pages,
+			"seconds":  seconds,
+			"sessions": sessions,
+		})
+		totalPages += pages
+		totalSeconds += seconds
+		totalSessions += sessions
+	}
+	rows.Close()
+
+	// 完成率：已读完的漫画占开始阅读过的漫画的比例
+	var startedComics, completedComics, totalComics int64
+	err = a.db.QueryRow(`
+	SELECT COUNT(*),
+		COALESCE(SUM(CASE WHEN is_read = 1 OR last_read_at IS NOT NULL OR id IN (SELECT comic_id FROM reading_sessions) THEN 1 ELSE 0 END), 0),
+		COALESCE(SUM(CASE WHEN is_read = 1 THEN 1 ELSE 0 END), 0)
+	FROM comics`).Scan(&totalComics, &startedComics, &completedComics)
+	if err != nil {
+		return nil, fmt.Errorf("统计完成率失败: %v", err)
+	}
+
+	completionRate := 0.0
+	if startedComics > 0 {
+		completionRate = float64(completedComics) / float64(startedComics)
+	}
+
+	// 阅读最多的系列
+	seriesQuery := `
+	SELECT sr.id, sr.name, SUM(s.pages_turned) AS pages, SUM(s.ended_at - s.started_at), COUNT(DISTINCT s.comic_id)
+	FROM reading_sessions s
+	JOIN comics c ON c.id = s.comic_id
+	JOIN series sr ON sr.id = c.series_id
+	WHERE s.started_at >= ?
+	GROUP BY sr.id
+	ORDER BY pages DESC
+	LIMIT 10`
+
+	rows, err = a.db.Query(seriesQuery, since)
+	if err != nil {
+		return nil, fmt.Errorf("统计系列阅读失败: %v", err)
+	}
+	defer rows.Close()
+
+	var topSeries []map[string]interface{}
+	for rows.Next() {
+		var id, pages, seconds, comics int64
+		var name string
+		if err := rows.Scan(&id, &name, &pages, &seconds, &comics); err != nil {
+			continue
+		}
+		topSeries = append(topSeries, map[string]interface{}{
+			"id":      id,
+			"name":    name,
+			"pages":   pages,
+			"seconds": seconds,
+			"comics":  comics,
+		})
+	}
+
+	return map[string]interface{}{
+		"days":            days,
+		"daily":           daily,
+		"totalPages":      totalPages,
+		"totalSeconds":    totalSeconds,
+		"totalSessions":   totalSessions,
+		"totalComics":     totalComics,
+		"startedComics":   startedComics,
+		"completedComics": completedComics,
+		"completionRate":  completionRate,
+		"topSeries":       topSeries,
+	}, nil
+}
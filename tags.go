@@ -0,0 +1,216 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// createTagTables 创建标签表及漫画与标签的多对多关联表
+func (a *App) createTagTables() error {
+	createTagTable := `
+	CREATE TABLE IF NOT EXISTS tags (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT UNIQUE NOT NULL COLLATE NOCASE,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);`
+
+	createComicTagTable := `
+	CREATE TABLE IF NOT EXISTS comic_tags (
+		comic_id INTEGER NOT NULL,
+		tag_id INTEGER NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (comic_id, tag_id),
+		FOREIGN KEY (comic_id) REFERENCES comics (id),
+		FOREIGN KEY (tag_id) REFERENCES tags (id)
+	);`
+
+	_, err := a.db.Exec(createTagTable)
+	if err != nil {
+		return fmt.Errorf("创建tags表失败: %v", err)
+	}
+
+	_, err = a.db.Exec(createComicTagTable)
+	if err != nil {
+		return fmt.Errorf("创建comic_tags表失败: %v", err)
+	}
+
+	return nil
+}
+
+// getOrCreateTag 按名称查找标签，不存在时创建（名称不区分大小写）
+func (a *App) getOrCreateTag(name string) (int64, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return 0, fmt.Errorf("标签名不能为空")
+	}
+
+	var tagID int64
+	err := a.db.QueryRow(`SELECT id FROM tags WHERE name = ?`, name).Scan(&tagID)
+	if err == nil {
+		return tagID, nil
+	}
+	if err != sql.ErrNoRows {
+		return 0, fmt.Errorf("查询标签失败: %v", err)
+	}
+
+	result, err := a.db.Exec(`INSERT INTO tags (name) VALUES (?)`, name)
+	if err != nil {
+		return 0, fmt.Errorf("创建标签失败: %v", err)
+	}
+
+	return result.LastInsertId()
+}
+
+// GetTags 获取所有标签及使用次数
+func (a *App) GetTags() ([]map[string]interface{}, error) {
+	if a.db == nil {
+		return nil, fmt.Errorf("数据库未初始化")
+	}
+
+	query := `
+	SELECT t.id, t.name, COUNT(ct.comic_id)
+	FROM tags t
+	LEFT JOIN comic_tags ct ON ct.tag_id = t.id
+	GROUP BY t.id
+	ORDER BY t.name COLLATE NATURAL_ORDER`
+
+	rows, err := a.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("查询标签失败: %v", err)
+	}
+	defer rows.Close()
+
+	var tags []map[string]interface{}
+	for rows.Next() {
+		var id, comicCount int64
+		var name string
+
+		if err := rows.Scan(&id, &name, &comicCount); err != nil {
+			continue
+		}
+
+		tags = append(tags, map[string]interface{}{
+			"id":         id,
+			"name":       name,
+			"comicCount": comicCount,
+		})
+	}
+
+	return tags, nil
+}
+
+// GetComicTags 获取漫画的所有标签名
+func (a *App) GetComicTags(comicID int64) ([]string, error) {
+	if a.db == nil {
+		return nil, fmt.Errorf("数据库未初始化")
+	}
+
+	query := `
+	SELECT t.name
+	FROM tags t
+	JOIN comic_tags ct ON ct.tag_id = t.id
+	WHERE ct.comic_id = ?
+	ORDER BY t.name COLLATE NATURAL_ORDER`
+
+	rows, err := a.db.Query(query, comicID)
+	if err != nil {
+		return nil, fmt.Errorf("查询漫画标签失败: %v", err)
+	}
+	defer rows.Close()
+
+	var tags []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			continue
+		}
+		tags = append(tags, name)
+	}
+
+	return tags, nil
+}
+
+// AddTagToComic 为漫画添加标签，标签不存在时自动创建
+func (a *App) AddTagToComic(comicID int64, tagName string) error {
+	if a.db == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+
+	tagID, err := a.getOrCreateTag(tagName)
+	if err != nil {
+		return err
+	}
+
+	_, err = a.db.Exec(`INSERT OR IGNORE INTO comic_tags (comic_id, tag_id) VALUES (?, ?)`, comicID, tagID)
+	if err != nil {
+		return fmt.Errorf("添加漫画标签失败: %v", err)
+	}
+
+	return nil
+}
+
+// RemoveTagFromComic 移除漫画的标签
+func (a *App) RemoveTagFromComic(comicID int64, tagName string) error {
+	if a.db == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+
+	query := `DELETE FROM comic_tags WHERE comic_id = ? AND tag_id IN (SELECT id FROM tags WHERE name = ?)`
+	_, err := a.db.Exec(query, comicID, strings.TrimSpace(tagName))
+	if err != nil {
+		return fmt.Errorf("移除漫画标签失败: %v", err)
+	}
+
+	return nil
+}
+
+// RenameTag 重命名标签
+func (a *App) RenameTag(tagID int64, name string) error {
+	if a.db == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return fmt.Errorf("标签名不能为空")
+	}
+
+	_, err := a.db.Exec(`UPDATE tags SET name = ? WHERE id = ?`, name, tagID)
+	if err != nil {
+		return fmt.Errorf("重命名标签失败: %v", err)
+	}
+
+	return nil
+}
+
+// DeleteTag 删除标签及其与漫画的关联
+func (a *App) DeleteTag(tagID int64) error {
+	if a.db == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+
+	_, err := a.db.Exec(`DELETE FROM comic_tags WHERE tag_id = ?`, tagID)
+	if err != nil {
+		return fmt.Errorf("删除标签关联失败: %v", err)
+	}
+
+	_, err = a.db.Exec(`DELETE FROM tags WHERE id = ?`, tagID)
+	if err != nil {
+		return fmt.Errorf("删除标签失败: %v", err)
+	}
+
+	return nil
+}
+
+// GetComicsByTag 获取带有指定标签的漫画
+func (a *App) GetComicsByTag(tagName string) ([]map[string]interface{}, error) {
+	query := `SELECT ` + comicSelectColumns + `
+			  FROM comics c
+			  JOIN comic_tags ct ON ct.comic_id = c.id
+			  JOIN tags t ON t.id = ct.tag_id
+			  WHERE t.name = ?
+			  ORDER BY c.updated_at DESC`
+
+	return a.queryComics(query, strings.TrimSpace(tagName))
+}
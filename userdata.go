@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// createUserDataColumns 为漫画表添加评分、收藏和备注字段
+func (a *App) createUserDataColumns() error {
+	userDataColumns := [][2]string{
+		{"rating", "REAL DEFAULT 0"},
+		{"is_favorite", "INTEGER DEFAULT 0"},
+		{"notes", "TEXT DEFAULT ''"},
+	}
+	for _, column := range userDataColumns {
+		if err := a.ensureColumn("comics", column[0], column[1]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SetComicRating 设置漫画评分，范围0到5，支持半星
+func (a *App) SetComicRating(comicID int64, rating float64) error {
+	if a.db == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+
+	if rating < 0 || rating > 5 || math.Mod(rating*2, 1) != 0 {
+		return fmt.Errorf("无效的评分: %v，评分须为0到5之间的整星或半星", rating)
+	}
+
+	_, err := a.db.Exec(`UPDATE comics SET rating = ?, updated_at = ? WHERE id = ?`, rating, time.Now(), comicID)
+	if err != nil {
+		return fmt.Errorf("更新评分失败: %v", err)
+	}
+
+	return nil
+}
+
+// SetComicFavorite 设置或取消收藏
+func (a *App) SetComicFavorite(comicID int64, favorite bool) error {
+	if a.db == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+
+	_, err := a.db.Exec(`UPDATE comics SET is_favorite = ?, updated_at = ? WHERE id = ?`, favorite, time.Now(), comicID)
+	if err != nil {
+		return fmt.Errorf("更新收藏状态失败: %v", err)
+	}
+
+	return nil
+}
+
+// SetComicNotes 保存漫画备注
+func (a *App) SetComicNotes(comicID int64, notes string) error {
+	if a.db == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+
+	_, err := a.db.Exec(`UPDATE comics SET notes = ?, updated_at = ? WHERE id = ?`, notes, time.Now(), comicID)
+	if err != nil {
+		return fmt.Errorf("保存备注失败: %v", err)
+	}
+
+	return nil
+}
+
+// GetComicUserData 获取漫画的评分、收藏状态和备注
+func (a *App) GetComicUserData(comicID int64) (map[string]interface{}, error) {
+	if a.db == nil {
+		return nil, fmt.Errorf("数据库未初始化")
+	}
+
+	var rating float64
+	var isFavorite bool
+	var notes string
+	err := a.db.QueryRow(`SELECT COALESCE(rating, 0), COALESCE(is_favorite, 0), COALESCE(notes, '') FROM comics WHERE id = ?`, comicID).
+		Scan(&rating, &isFavorite, &notes)
+	if err != nil {
+		return nil, fmt.Errorf("查询漫画信息失败: %v", err)
+	}
+
+	return map[string]interface{}{
+		"comicId":    comicID,
+		"rating":     rating,
+		"isFavorite": isFavorite,
+		"notes":      notes,
+	}, nil
+}